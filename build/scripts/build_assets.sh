@@ -7,9 +7,12 @@ FROM="${1:-/tmp/cows/}"
 go run ./src/bin/pokedex/pokedex.go \
   -from "${FROM}" \
   -fromMetadata "${FROM}/pokemon.json" \
+  -fromEvolutions "${FROM}/evolutions.json" \
+  -fromTypes "${FROM}/types.json" \
   -to ./build/assets/ \
   -toDataSubDir cows/ \
   -toMetadataSubDir metadata/ \
-  -toTotalFname total.txt
+  -toTotalFname total.txt \
+  -legacyIDs ./build/legacy_ids.txt
 
 rm -rf cows
@@ -10,29 +10,36 @@ test -f "/usr/local/opt/coreutils/libexec/gnubin" && \
 OUTPUT_DIR="dist/bin"
 mkdir -p "$OUTPUT_DIR"
 
-cat build/packages/pokesay.1 | \
-  sed -e "s/DATE/$(date '+%B %Y')/g" \
-      -e "s/VERSION/$VERSION/g" > pokesay.1
-
 function build() {
     echo -e "  - building $1 / $2"
     GOOS=$1 GOARCH=$2 go build \
+      -ldflags "-X main.Version=${VERSION}" \
       -o "${OUTPUT_DIR}/pokesay-${VERSION}-${1}-${2}${3:-}" \
-      pokesay.go > /dev/null 2>&1
+      . > /dev/null 2>&1
     echo -e "  \e[1;32m✔ built as ${OUTPUT_DIR}/pokesay-${VERSION}-${1}-${2}${3:-}\e[0m"
 }
 
+# generate the man page & shell completion scripts from the linux/amd64 binary, so that they match its flags
+function docs() {
+    local bin="${OUTPUT_DIR}/pokesay-${VERSION}-linux-amd64"
+
+    "$bin" --generate-man > dist/pokesay.1
+    mkdir -p dist/completions
+    for shell in bash zsh fish; do
+        "$bin" completion "$shell" > "dist/completions/pokesay-completion.${shell}"
+    done
+}
+
 function tarball() {
     echo "  - tarballing $1 / $2"
     local binfile="pokesay-${VERSION}-${1}-${2}${3:-}"
 
     cp \
         "$OUTPUT_DIR"/pokesay-* \
-        build/packages/pokesay-completion.bash \
-        build/packages/pokesay-completion.zsh \
-        build/packages/pokesay-completion.fish \
-        build/packages/pokesay-names.txt \
-        build/packages/pokesay-ids.txt \
+        dist/pokesay.1 \
+        dist/completions/pokesay-completion.bash \
+        dist/completions/pokesay-completion.zsh \
+        dist/completions/pokesay-completion.fish \
         .
 
     # create a tarball for the linux/amd64 binary (used for AUR package)
@@ -43,12 +50,7 @@ function tarball() {
         pokesay.1 \
         pokesay-completion.bash \
         pokesay-completion.zsh \
-        pokesay-completion.fish \
-        pokesay-names.txt \
-        pokesay-ids.txt
-
-    mkdir -p usr/share/pokesay
-    mv pokesay-names.txt pokesay-ids.txt usr/share/pokesay/
+        pokesay-completion.fish
 
     # create a full tarball with all binaries and files (used for homebrew formula)
     tar czf \
@@ -58,14 +60,14 @@ function tarball() {
         pokesay.1 \
         pokesay-completion.bash \
         pokesay-completion.zsh \
-        pokesay-completion.fish \
-        usr/share/pokesay/
+        pokesay-completion.fish
     echo -e "  \e[1;32m✔ tarballed as dist/tarballs/${binfile}.tar.gz\e[0m"
 
-    rm -rf pokesay.1 pokesay-* usr/
+    rm -rf pokesay.1 pokesay-*
 }
 
 build linux   amd64
+docs
 
 build darwin  amd64 &
 build darwin  arm64 &
@@ -73,6 +75,3 @@ build windows amd64 .exe &
 build android arm64 &
 tarball linux amd64 & # just create a tarball for the linux/amd64 (used for AUR package)
 wait
-
-
-rm -f pokesay.1
@@ -35,6 +35,8 @@ done
 mv -v /tmp/cows/pokemon-gen8 /tmp/cows/gen8
 mv -v /tmp/cows/pokemon-gen7x /tmp/cows/gen7x
 cat /tmp/original/cows/data/pokemon.json | jq -c .[] > /tmp/cows/pokemon.json
+/usr/local/src/build/scripts/fetch_evolutions.sh /tmp/cows/evolutions.json
+/usr/local/src/build/scripts/fetch_types.sh /tmp/cows/types.json
 
 nNewFpaths=$(find /tmp/cows/ -iname '*.cow' | wc -l | tr -d '\n')
 echo -e "\n\e[1;32m✔ all done, total files: $nNewFpaths / $nOldFpaths\e[0m"
@@ -30,7 +30,8 @@ function build_deb() {
         "$pkg_name/pokesay/usr/share/man/man1"
 
     cp "$bin" "$pkg_name/pokesay/usr/bin/pokesay"
-    gzip -c "docs/pokesay.1" > "$pkg_name/pokesay/usr/share/man/man1/pokesay.1.gz"
+    # the man page is generated by the binary itself (using the linux binary, which can run on this host)
+    build/bin/pokesay-linux-amd64 --generate-man | gzip -c > "$pkg_name/pokesay/usr/share/man/man1/pokesay.1.gz"
 
     cat > "$pkg_name/pokesay/DEBIAN/control" <<EOF
 Package: pokesay
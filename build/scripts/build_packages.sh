@@ -24,21 +24,15 @@ function build_deb() {
         "$pkg_name/pokesay/usr/share/man/man1" \
         "$pkg_name/pokesay/usr/share/bash-completion/completions" \
         "$pkg_name/pokesay/usr/share/zsh/site-functions" \
-        "$pkg_name/pokesay/usr/share/fish/vendor_completions.d" \
-        "$pkg_name/pokesay/usr/share/pokesay"
+        "$pkg_name/pokesay/usr/share/fish/vendor_completions.d"
 
     cp "$bin" "$pkg_name/pokesay/usr/bin/pokesay"
-    cat build/packages/pokesay.1 | \
-      sed -e "s/DATE/$(date '+%B %Y')/g" \
-          -e "s/VERSION/$VERSION/g" | \
-      gzip -c > "$pkg_name/pokesay/usr/share/man/man1/pokesay.1.gz"
-
-    # Add completions and data files
-    cp build/packages/pokesay-completion.bash "$pkg_name/pokesay/usr/share/bash-completion/completions/pokesay"
-    cp build/packages/pokesay-completion.zsh "$pkg_name/pokesay/usr/share/zsh/site-functions/_pokesay"
-    cp build/packages/pokesay-completion.fish "$pkg_name/pokesay/usr/share/fish/vendor_completions.d/pokesay.fish"
-    cp build/packages/pokesay-names.txt "$pkg_name/pokesay/usr/share/pokesay/pokesay-names.txt"
-    cp build/packages/pokesay-ids.txt "$pkg_name/pokesay/usr/share/pokesay/pokesay-ids.txt"
+    # the man page & completions are generated by build_bin.sh
+    gzip -c dist/pokesay.1 > "$pkg_name/pokesay/usr/share/man/man1/pokesay.1.gz"
+
+    cp dist/completions/pokesay-completion.bash "$pkg_name/pokesay/usr/share/bash-completion/completions/pokesay"
+    cp dist/completions/pokesay-completion.zsh "$pkg_name/pokesay/usr/share/zsh/site-functions/_pokesay"
+    cp dist/completions/pokesay-completion.fish "$pkg_name/pokesay/usr/share/fish/vendor_completions.d/pokesay.fish"
 
     cat build/packages/DEBIAN/control | \
       sed -e "s/VERSION/$VERSION/g" \
@@ -64,10 +58,7 @@ function build_arch() {
     mkdir -p "$ARCH_DIR"
 
     cp "dist/bin/$BIN_FILE" "$ARCH_DIR/"
-    cat "build/packages/pokesay.1" | \
-      sed -e "s/DATE/$(date '+%B %Y')/g" \
-          -e "s/VERSION/$VERSION/g" \
-      > "$ARCH_DIR/pokesay.1"
+    cp dist/pokesay.1 "$ARCH_DIR/"
     cp LICENSE "$ARCH_DIR/"
 
     SHA256_SUM=$(sha256sum "$ARCH_DIR/$BIN_FILE" | cut -d' ' -f1)
@@ -81,11 +72,9 @@ function build_arch() {
           -e "s/SHA256_SUM/$SHA256_SUM/g" \
       > "$ARCH_DIR/PKGBUILD"
 
-    cp build/packages/pokesay-completion.bash "$ARCH_DIR/"
-    cp build/packages/pokesay-completion.zsh "$ARCH_DIR/"
-    cp build/packages/pokesay-completion.fish "$ARCH_DIR/"
-    cp build/packages/pokesay-names.txt "$ARCH_DIR/"
-    cp build/packages/pokesay-ids.txt "$ARCH_DIR/"
+    cp dist/completions/pokesay-completion.bash "$ARCH_DIR/"
+    cp dist/completions/pokesay-completion.zsh "$ARCH_DIR/"
+    cp dist/completions/pokesay-completion.fish "$ARCH_DIR/"
 
     chown -R u:u "$ARCH_DIR"
 
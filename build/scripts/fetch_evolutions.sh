@@ -0,0 +1,24 @@
+#!/bin/bash
+
+set -euo pipefail
+
+# Fetches every evolution chain from PokeAPI, and writes them to a file with one chain per line,
+# as a list of evolution lines (the paths from the first pokemon to each final evolution), e.g.
+# [["wurmple","silcoon","beautifly"],["wurmple","cascoon","dustox"]]
+
+TO="${1:-/tmp/cows/evolutions.json}"
+API="https://pokeapi.co/api/v2"
+
+lines='def lines: .species.name as $n | if (.evolves_to | length) == 0 then [$n] else .evolves_to[] | [$n] + lines end; [.chain | lines]'
+
+urls=$(curl -sSf "${API}/evolution-chain?limit=10000" | jq -r '.results[].url')
+nUrls=$(echo "$urls" | wc -l | tr -d '\n')
+
+i=1
+echo "$urls" | while read url; do
+  echo -e "[$i/$nUrls] \e[0;32m fetching: $url\e[0m" >&2
+  curl -sSf "$url" | jq -c "$lines"
+  ((i++))
+done > "$TO"
+
+echo -e "\n\e[1;32m✔ all done, wrote $nUrls evolution chains to $TO\e[0m"
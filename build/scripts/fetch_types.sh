@@ -0,0 +1,26 @@
+#!/bin/bash
+
+set -euo pipefail
+
+# Fetches the types of every pokemon from the PokeAPI data, and writes them to a file with one pokemon per line,
+# by national dex number, e.g. {"idx":"006","types":["fire","flying"]}
+
+TO="${1:-/tmp/cows/types.json}"
+CSV="https://raw.githubusercontent.com/PokeAPI/pokeapi/master/data/v2/csv"
+
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+curl -sSf "${CSV}/types.csv" -o "$tmp/types.csv"
+curl -sSf "${CSV}/pokemon_types.csv" -o "$tmp/pokemon_types.csv"
+
+# types.csv:         id,identifier,generation_id,damage_class_id
+# pokemon_types.csv: pokemon_id,type_id,slot (sorted by pokemon & slot)
+# the default form of each pokemon has the same ID as its national dex number, and alternate forms start at 10001
+awk -F, '
+  NR == FNR { if (FNR > 1) names[$1] = $2; next }
+  FNR > 1 && $1 < 10000 { types[$1] = types[$1] (types[$1] == "" ? "" : ",") "\"" names[$2] "\"" }
+  END { for (id in types) printf "{\"idx\":\"%03d\",\"types\":[%s]}\n", id, types[id] }
+' "$tmp/types.csv" "$tmp/pokemon_types.csv" | sort > "$TO"
+
+echo -e "\e[1;32m✔ all done, wrote the types of $(wc -l < "$TO" | tr -d ' ') pokemon to $TO\e[0m"
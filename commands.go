@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pborman/getopt/v2"
+	"github.com/tmck-code/pokesay/src/pokesay"
+	"golang.org/x/term"
+)
+
+// Command is a pokesay subcommand, e.g. `pokesay list names`
+type Command struct {
+	Name  string
+	Usage string // the parameters of the command, e.g. "<name|id>"
+	Help  string
+	Run   func(args pokesay.Args)
+	// the values that the shell completion scripts offer for the first parameter
+	Complete pokesay.Completion
+}
+
+// Commands are the subcommands of pokesay. When the first parameter isn't a command, or the rest don't match its usage,
+// the parameters are the message, and pokesay prints a random pokemon (the same as `pokesay random`)
+var Commands []Command
+
+func init() {
+	Commands = []Command{
+		{"random", "", "print a random pokemon (honours --name, --category, --id & --dex)", runRandomCommand, pokesay.Completion{}},
+		{"show", "<name|id>", "print the pokemon with a name or ID", runShowCommand, pokesay.Completion{Command: "list names"}},
+		{"list", "names|categories|ids", "list all pokemon names, categories or IDs", runListCommand, pokesay.Completion{Words: []string{"names", "categories", "ids"}}},
+		{"search", "<query>", "list the names of all pokemon that contain the query", runSearchCommand, pokesay.Completion{}},
+		{"info", "<name|id>", "print the information about a pokemon and all of its sprites", runInfoCommand, pokesay.Completion{Command: "list names"}},
+		{"quiz", "", "show the silhouette of a random pokemon (honours --name, --category, --id & --dex), and guess who it is", runQuizCommand, pokesay.Completion{}},
+		{"browse", "", "browse all pokemon in a full-screen terminal UI, with search, category filters & a sprite preview", runBrowseCommand, pokesay.Completion{}},
+		{"completion", strings.Join(pokesay.CompletionShells, "|"), "print the shell completion script for bash, zsh or fish", runCompletionCommand, pokesay.Completion{Words: pokesay.CompletionShells}},
+	}
+}
+
+// flagCompletions are the values that the shell completion scripts offer for the flags that take a value, by long name.
+// The names, IDs & categories are completed by calling pokesay, so they always match the embedded pokemon
+var flagCompletions map[string]pokesay.Completion = map[string]pokesay.Completion{
+	"name":         {Command: "list names"},
+	"id":           {Command: "list ids"},
+	"category":     {Command: "list categories"},
+	"config":       {Files: true},
+	"pack":         {Dirs: true},
+	"cowfile":      {Files: true},
+	"export-cow":   {Command: "list names"},
+	"format":       {Words: pokesay.ListFormats},
+	"sort":         {Words: pokesay.ListColumns},
+	"border-style": {Words: pokesay.BorderStyleNames()},
+	"scale":        {Words: []string{"0.5", "2", "3"}},
+	"shiny-odds":   {Words: []string{"1/4096", "1/8192", "1/512"}},
+	"filter":       {Words: pokesay.Filters},
+	"animate":      {Words: pokesay.AnimateModes},
+	"bubble-color": {Words: append(pokesay.ColourNames(), pokesay.AutoColour)},
+	"text-color":   {Words: pokesay.ColourNames()},
+}
+
+// findCommand returns the command with a name, or nil if there isn't one
+func findCommand(name string) *Command {
+	for i, command := range Commands {
+		if command.Name == name {
+			return &Commands[i]
+		}
+	}
+	return nil
+}
+
+// matchCommand returns the command named by the first parameter, if the rest of the parameters match its usage.
+// Otherwise it returns nil, and the parameters are a message that may start with the name of a command,
+// e.g. `pokesay show me the money`
+func matchCommand(params []string) *Command {
+	if len(params) == 0 {
+		return nil
+	}
+	command := findCommand(params[0])
+	if command == nil || !pokesay.MatchUsage(command.Usage, params[1:]) {
+		return nil
+	}
+	return command
+}
+
+// printUsage prints the usage of all flags (from getopt), and then the subcommands
+func printUsage() {
+	getopt.PrintUsage(os.Stderr)
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, command := range Commands {
+		fmt.Fprintf(os.Stderr, "  %-28s %s\n", strings.TrimSpace(command.Name+" "+command.Usage), command.Help)
+	}
+}
+
+// commandParam returns the only parameter of a command, or exits with the command usage if there isn't one
+func commandParam(args pokesay.Args) string {
+	if len(args.CommandArgs) != 1 {
+		command := findCommand(args.Command)
+		log.Fatalf("usage: pokesay %s %s", command.Name, command.Usage)
+	}
+	return args.CommandArgs[0]
+}
+
+// printList prints a list of strings, one per line, or as a JSON array with --format=json.
+// The other formats are written in the same way as --list-names, with the items in a single column
+func printList(args pokesay.Args, column string, items []string) {
+	switch args.Format {
+	case "json":
+		printJSON(items)
+		return
+	case "":
+		args.Format = "plain"
+	}
+	rows := make([][]string, 0, len(items))
+	for _, item := range items {
+		rows = append(rows, []string{item})
+	}
+	if err := pokesay.WriteRows(os.Stdout, args.Format, []string{column}, rows); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printJSON(obj interface{}) {
+	data, err := json.MarshalIndent(obj, "", strings.Repeat(" ", 2))
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+// runRandomCommand prints a pokemon, using the same selection flags as plain `pokesay`
+func runRandomCommand(args pokesay.Args) {
+	runPrint(args)
+}
+
+// chooseByToken chooses the pokemon with a name (with --category if given), or else with an ID
+func chooseByToken(args pokesay.Args, token string) pokesay.Choice {
+	var choice pokesay.Choice
+	var err error
+	_, isName := Dex.Packs.Names()[token]
+	_, _, idErr := pokesay.ParseID(token)
+	isID := idErr == nil || strings.Contains(token, "/")
+
+	if isName && args.Category != "" {
+		choice, err = Dex.ByNameAndCategory(token, args.Category)
+	} else if isName || !isID {
+		choice, err = Dex.ByName(token)
+	} else {
+		choice, err = Dex.ByID(token)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return choice
+}
+
+// runShowCommand prints the pokemon with a name (with --category if given), or else with an ID
+func runShowCommand(args pokesay.Args) {
+	token := commandParam(args)
+	choice := chooseByToken(args, token)
+	if _, isName := Dex.Packs.Names()[token]; !isName {
+		// a sprite shown by ID isn't swapped by --shiny-odds
+		args.IDToken = token
+	}
+	printChoice(args, choice)
+}
+
+// runListCommand lists all pokemon names, categories or IDs
+func runListCommand(args pokesay.Args) {
+	switch commandParam(args) {
+	case "names":
+		printList(args, "name", Dex.Names())
+	case "categories":
+		printList(args, "category", Dex.Categories())
+	case "ids":
+		choices, err := Dex.Choices()
+		if err != nil {
+			log.Fatal(err)
+		}
+		ids := make([]string, 0)
+		for _, choice := range choices {
+			ids = append(ids, choice.ID)
+		}
+		printList(args, "id", ids)
+	default:
+		log.Fatal("usage: pokesay list names|categories|ids")
+	}
+}
+
+// runSearchCommand lists the names of all pokemon that contain the query
+func runSearchCommand(args pokesay.Args) {
+	query := commandParam(args)
+	matches := Dex.Search(query)
+	if len(matches) == 0 {
+		log.Fatalf("cannot find any pokemon matching '%s'", query)
+	}
+	printList(args, "name", matches)
+}
+
+// PokemonInfo is the information about a pokemon printed by `pokesay info`
+type PokemonInfo struct {
+	Name             string
+	JapaneseName     string
+	JapanesePhonetic string
+	DexNumber        int
+	Types            []string
+	Generation       int
+	Forms            []string
+	Sprites          []SpriteInfo
+}
+
+// SpriteInfo is the information about a single sprite of a pokemon printed by `pokesay info`
+type SpriteInfo struct {
+	ID         string
+	Categories []string
+}
+
+// runInfoCommand prints the information about a pokemon and all of its sprites, as plain text or JSON.
+// A name can match more than one pokemon (e.g. from different packs), in which case all are printed
+func runInfoCommand(args pokesay.Args) {
+	token := commandParam(args)
+	if args.Format != "" && args.Format != "plain" && args.Format != "json" {
+		log.Fatalf("invalid format '%s' for the info command, expected one of: plain, json", args.Format)
+	}
+
+	choices, err := Dex.Lookup(token)
+	if err != nil {
+		choice, idErr := Dex.ByID(token)
+		if idErr != nil {
+			log.Fatal(err)
+		}
+		// show all of the sprites of the pokemon, not just the one with the ID
+		choices = Dex.Sprites(choice)
+	}
+
+	infos := make([]PokemonInfo, 0)
+	for i, choice := range choices {
+		if i == 0 || choice.Metadata.Idx != choices[i-1].Metadata.Idx || choice.Pack != choices[i-1].Pack {
+			infos = append(infos, PokemonInfo{
+				Name:             choice.Metadata.Name,
+				JapaneseName:     choice.Metadata.JapaneseName,
+				JapanesePhonetic: choice.Metadata.JapanesePhonetic,
+				DexNumber:        choice.Metadata.DexNumber,
+				Types:            choice.Metadata.Types,
+				Generation:       choice.Metadata.Generation,
+				Forms:            choice.Metadata.Forms,
+			})
+		}
+		info := &infos[len(infos)-1]
+		info.Sprites = append(info.Sprites, SpriteInfo{ID: choice.ID, Categories: choice.Entry.Categories})
+	}
+
+	if args.Format == "json" {
+		printJSON(infos)
+		return
+	}
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Name:          %s\n", info.Name)
+		if info.JapaneseName != "" {
+			fmt.Printf("Japanese name: %s (%s)\n", info.JapaneseName, info.JapanesePhonetic)
+		}
+		if info.DexNumber > 0 {
+			fmt.Printf("Dex number:    #%04d\n", info.DexNumber)
+		}
+		if len(info.Types) > 0 {
+			fmt.Printf("Types:         %s\n", strings.Join(info.Types, "/"))
+		}
+		if info.Generation > 0 {
+			fmt.Printf("Generation:    %d\n", info.Generation)
+		}
+		if len(info.Forms) > 0 {
+			fmt.Printf("Forms:         %s\n", strings.Join(info.Forms, ", "))
+		}
+		fmt.Println("Sprites:")
+		for _, sprite := range info.Sprites {
+			fmt.Printf("  %-36s %s\n", sprite.ID, strings.Join(sprite.Categories, ", "))
+		}
+	}
+}
+
+// runQuizCommand plays "Who's that Pokémon?"
+// - This prints the silhouette of a random pokemon, without its name or categories
+// - It reads a guess from stdin, and matches it to the closest name (so that small typos are OK)
+// - Finally, it reveals the pokemon, and the streak of correct guesses (which is kept in the quiz state file)
+func runQuizCommand(args pokesay.Args) {
+	if len(args.CommandArgs) > 0 {
+		log.Fatal("usage: pokesay quiz")
+	}
+	fpath := pokesay.QuizStateFpath()
+	state, err := pokesay.ReadQuizState(fpath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	choice := chooseChoice(args)
+	sprite, err := Dex.Sprite(choice)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hidden := args
+	hidden.Filter, hidden.NoCategoryInfo = pokesay.FilterSilhouette, true
+	hidden.Message = strings.NewReader("Who's that Pokémon?")
+	pokesay.PrintSprite(hidden, sprite, []string{"???"}, []string{})
+
+	fmt.Print("Your guess: ")
+	guess, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		log.Fatal(err)
+	}
+	match := pokesay.MatchGuess(guess, Dex.Names())
+	correct := match != "" && pokesay.IsSameName(match, choice.Metadata.Name)
+	if choices, err := Dex.Lookup(match); err == nil && len(choices) > 0 {
+		// the names are lowercase, so show the name of the pokemon instead, e.g. "mr. mime" -> "Mr. Mime"
+		match = choices[0].Metadata.Name
+	}
+	state.Record(correct)
+	if err := pokesay.WriteQuizState(fpath, state); err != nil {
+		log.Fatal(err)
+	}
+
+	result := fmt.Sprintf("Yes, it's %s!", choice.Metadata.Name)
+	if !correct && match != "" {
+		result = fmt.Sprintf("No, it's %s, not %s!", choice.Metadata.Name, match)
+	} else if !correct {
+		result = fmt.Sprintf("No, it's %s!", choice.Metadata.Name)
+	}
+	args.Message = strings.NewReader(fmt.Sprintf("%s\nStreak: %d (best: %d)", result, state.Streak, state.Best))
+	fmt.Println()
+	pokesay.PrintSprite(args, sprite, GenerateNames(choice, args, false), pathCategories(choice.Entry.Categories))
+}
+
+// runBrowseCommand opens the full-screen pokedex browser, which reads single key presses from the terminal.
+// The browser is redrawn after every key press (so resizing the terminal takes effect on the next key),
+// and the ID or command line of the selected sprite is copied to the clipboard with an OSC 52 escape sequence
+func runBrowseCommand(args pokesay.Args) {
+	if len(args.CommandArgs) > 0 {
+		log.Fatal("usage: pokesay browse")
+	}
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	if !term.IsTerminal(in) || !term.IsTerminal(out) {
+		log.Fatal("pokesay browse needs a terminal")
+	}
+	choices, err := Dex.Choices()
+	if err != nil {
+		log.Fatal(err)
+	}
+	browser := pokesay.NewBrowser(choices)
+	browser.Flip, browser.Filter = args.Flip != "", args.Filter
+
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer term.Restore(in, state)
+	// use the alternate screen (so the terminal is restored afterwards), and hide the cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 256)
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			width, height = 80, 24
+		}
+		fmt.Print(browser.Render(width, height, browseSprite))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range pokesay.ParseKeys(buf[:n]) {
+			var text string
+			switch browser.HandleKey(key) {
+			case pokesay.BrowseQuit:
+				return
+			case pokesay.BrowseCopyID:
+				if choice, ok := browser.Selected(); ok {
+					text = choice.ID
+				}
+			case pokesay.BrowseCopyCommand:
+				text = browser.CommandLine()
+			}
+			if text != "" {
+				fmt.Print(pokesay.ClipboardSequence(text))
+				browser.Status = "copied: " + text
+			}
+		}
+	}
+}
+
+// browseSprite reads the sprite of a choice for the browser, showing the error in its place when the sprite can't be read
+func browseSprite(choice pokesay.Choice) []byte {
+	sprite, err := Dex.Sprite(choice)
+	if err != nil {
+		return []byte(err.Error())
+	}
+	return sprite
+}
+
+// flagInfos describes the registered flags from the FlagTable, for the completion scripts & man page
+func flagInfos() []pokesay.FlagInfo {
+	flags := make([]pokesay.FlagInfo, 0, len(FlagTable))
+	// visit the flags in the same order as the usage
+	getopt.VisitAll(func(opt getopt.Option) {
+		flag := FlagTable[opt.LongName()]
+		flag.Complete = flagCompletions[flag.Long]
+		flags = append(flags, flag)
+	})
+	return flags
+}
+
+// commandInfos describes the subcommands, for the completion scripts & man page
+func commandInfos() []pokesay.CommandInfo {
+	commands := make([]pokesay.CommandInfo, 0, len(Commands))
+	for _, command := range Commands {
+		commands = append(commands, pokesay.CommandInfo{
+			Name: command.Name, Usage: command.Usage, Help: command.Help, Complete: command.Complete,
+		})
+	}
+	return commands
+}
+
+// runCompletionCommand prints the completion script for a shell, generated from the registered flags & commands
+func runCompletionCommand(args pokesay.Args) {
+	if err := pokesay.WriteCompletion(os.Stdout, commandParam(args), "pokesay", flagInfos(), commandInfos()); err != nil {
+		log.Fatal(err)
+	}
+}
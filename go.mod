@@ -8,6 +8,7 @@ require (
 	github.com/mitchellh/go-wordwrap v1.0.1
 	github.com/pborman/getopt/v2 v2.1.0
 	github.com/schollz/progressbar/v3 v3.13.1
+	golang.org/x/term v0.6.0
 )
 
 require (
@@ -16,5 +17,4 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/mitchellh/colorstring v0.0.0-20190213212951-d06e56a500db // indirect
 	golang.org/x/sys v0.26.0 // indirect
-	golang.org/x/term v0.6.0 // indirect
 )
@@ -2,75 +2,237 @@ package main
 
 import (
 	"embed"
-	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pborman/getopt/v2"
 	"github.com/tmck-code/pokesay/src/pokedex"
 	"github.com/tmck-code/pokesay/src/pokesay"
 	"github.com/tmck-code/pokesay/src/timer"
+	"golang.org/x/term"
 )
 
 var (
-	//go:embed build/assets/category_keys.txt
-	GOBCategoryKeys []byte
-	//go:embed build/assets/names.txt
-	GOBAllNames []byte
-
-	//go:embed build/assets/total.txt
-	GOBTotal []byte
-	//go:embed build/assets/cows/*cow
-	GOBCowData embed.FS
-	//go:embed build/assets/metadata/*metadata
-	GOBCowNames embed.FS
-	//go:embed all:build/assets/categories
-	GOBCategories embed.FS
-
-	CategoryRoot string = "build/assets/categories" // the root directory of the pokemon categories
-	MetadataRoot string = "build/assets/metadata"   // the root directory of the pokemon metadata
-	CowDataRoot  string = "build/assets/cows"       // the root directory of the pokemon cow data
+	//go:embed build/assets/category_keys.txt build/assets/names.txt build/assets/dex.txt build/assets/ids.txt build/assets/legacy_ids.txt build/assets/total.txt
+	//go:embed build/assets/cows/*cow build/assets/metadata/*metadata all:build/assets/categories
+	GOBAssets embed.FS
+
+	AssetsRoot string = "build/assets" // the root directory of the embedded pokemon assets
+
+	// the embedded pokemon, merged with any packs loaded from $POKESAY_PACKS or --pack
+	Dex *pokesay.Pokedex
+
+	// the release version, set when building a release with -ldflags "-X main.Version=..."
+	Version string = "dev"
 )
 
+// FlagTable describes every flag registered in parseFlags by its long name, for the completion scripts & man page.
+// getopt doesn't expose the help text, value name or default of a flag, so they are recorded here as each flag is registered
+var FlagTable map[string]pokesay.FlagInfo = make(map[string]pokesay.FlagInfo)
+
+// newFlag describes a flag for the FlagTable. Flags that take a value without a value name are shown with "value"
+func newFlag(long string, short rune, help string, takesValue bool, defval string, valueName []string) pokesay.FlagInfo {
+	flag := pokesay.FlagInfo{Long: long, Help: help}
+	if short != 0 {
+		flag.Short = string(short)
+	}
+	if takesValue {
+		flag.Value = "value"
+		if len(valueName) > 0 {
+			flag.Value = valueName[0]
+		}
+		flag.Default = defval
+	}
+	return flag
+}
+
+// boolFlag registers a flag that doesn't take a value with getopt & the FlagTable, and the other *Flag functions
+// register flags that take a value of their type
+func boolFlag(long string, short rune, help string) *bool {
+	FlagTable[long] = newFlag(long, short, help, false, "", nil)
+	return getopt.BoolLong(long, short, help)
+}
+
+func stringFlag(long string, short rune, defval string, help string, valueName ...string) *string {
+	FlagTable[long] = newFlag(long, short, help, true, defval, valueName)
+	return getopt.StringLong(long, short, defval, append([]string{help}, valueName...)...)
+}
+
+func intFlag(long string, short rune, defval int, help string, valueName ...string) *int {
+	FlagTable[long] = newFlag(long, short, help, true, strconv.Itoa(defval), valueName)
+	return getopt.IntLong(long, short, defval, append([]string{help}, valueName...)...)
+}
+
+func durationFlag(long string, short rune, defval time.Duration, help string, valueName ...string) *time.Duration {
+	FlagTable[long] = newFlag(long, short, help, true, defval.String(), valueName)
+	return getopt.DurationLong(long, short, defval, append([]string{help}, valueName...)...)
+}
+
+// listFlag registers a flag that can be given more than once, e.g. --pack a --pack b
+func listFlag(long string, short rune, help string, valueName ...string) *[]string {
+	flag := newFlag(long, short, help, true, "", valueName)
+	flag.Repeatable = true
+	FlagTable[long] = flag
+	return getopt.ListLong(long, short, append([]string{help}, valueName...)...)
+}
+
+// optionalFlag makes the value of a registered flag optional, e.g. --flip or --flip=v
+func optionalFlag(long string) {
+	getopt.Lookup(long).SetOptional()
+	flag := FlagTable[long]
+	flag.Optional = true
+	FlagTable[long] = flag
+}
+
 // parseFlags parses the command line flags and returns a pokesay.Args struct
 func parseFlags() pokesay.Args {
-	help := getopt.BoolLong("help", 'h', "display this help message")
+	help := boolFlag("help", 'h', "display this help message")
+	generateMan := boolFlag("generate-man", 0, "print the man page (in roff) and exit")
 	// print verbose output (currently timer output)
-	verbose := getopt.BoolLong("verbose", 'v', "print verbose output", "verbose")
+	verbose := boolFlag("verbose", 'v', "print verbose output")
+	configFpath := stringFlag("config", 0, "", "read default options from a config file (default $XDG_CONFIG_HOME/pokesay/config.toml)", "file")
 
 	// selection/filtering
-	name := getopt.StringLong("name", 'n', "", "choose a pokemon from a specific name")
-	id := getopt.StringLong("id", 'i', "", "choose a pokemon from a specific ID (see `pokesay -l` for IDs)")
-	category := getopt.StringLong("category", 'c', "", "choose a pokemon from a specific category")
+	name := stringFlag("name", 'n', "", "choose a pokemon from a specific name")
+	id := stringFlag("id", 'i', "", "choose a pokemon from a specific ID, e.g. pikachu/gen8/shiny, 0025 or 0025.0123 (see `pokesay -l` for IDs)")
+	category := stringFlag("category", 'c', "", "choose a pokemon from a specific category (with --list-names, filter by a category expression, e.g. gen7x|gen8,!shiny)")
+	dex := stringFlag("dex", 'd', "", "choose a pokemon by national dex number, or a range of numbers (e.g. 25 or 1-151)")
+	shinyOdds := stringFlag("shiny-odds", 0, "", "choose the pokemon first, and then make it shiny with these odds, e.g. 1/4096 (by default, shiny & regular sprites are equally likely)", "odds")
 
 	// list operations
-	listNames := getopt.StringLong("list-names", 'l', "", "list all available names")
-	getopt.Lookup('l').SetOptional()
-	listCategories := getopt.BoolLong("list-categories", 'L', "list all available categories")
+	listNames := stringFlag("list-names", 'l', "", "list all available names, or those that contain a value")
+	optionalFlag("list-names")
+	listCategories := boolFlag("list-categories", 'L', "list all available categories")
+	columns := stringFlag("columns", 0, "id,name,categories", "the columns to show with --list-names --format, from "+strings.Join(pokesay.ListColumns, ","), "columns")
+	sortColumn := stringFlag("sort", 0, "id", "the column to sort --list-names --format by, prefixed with - to reverse, e.g. -size", "column")
+
+	// output format options
+	format := stringFlag("format", 0, "", "the output format of --list-names, and the list, search & info commands: "+strings.Join(pokesay.ListFormats, ", ")+" (by default, --list-names prints a {name -> {ID -> categories}} map, and commands print plain, info only supports plain & json)", "format")
+
+	// extra sprite packs
+	packs := listFlag("pack", 0, "load an extra sprite pack from a directory (can be given multiple times, see also $POKESAY_PACKS)", "dir")
 
-	width := getopt.IntLong("width", 'w', 80, "the max speech bubble width")
+	width := intFlag("width", 'w', 80, "the max speech bubble width")
+	messageFpath := stringFlag("file", 0, "", "read the message from a file, instead of the parameters or stdin", "file")
 
 	// speech bubble options
-	tabWidth := getopt.IntLong("tab-width", 't', 4, "replace any tab characters with N spaces")
-	noWrap := getopt.BoolLong("no-wrap", 'W', "disable text wrapping (fastest)")
-	noTabSpaces := getopt.BoolLong("no-tab-spaces", 's', "do not replace tab characters (fastest)")
-	fastest := getopt.BoolLong("fastest", 'f', "run with the fastest possible configuration (--nowrap & --notabspaces)")
-	noBubble := getopt.BoolLong("no-bubble", 'B', "do not draw the speech bubble")
+	tabWidth := intFlag("tab-width", 't', 4, "replace any tab characters with N spaces")
+	noWrap := boolFlag("no-wrap", 'W', "disable text wrapping (fastest)")
+	noTabSpaces := boolFlag("no-tab-spaces", 's', "do not replace tab characters (fastest)")
+	fastest := boolFlag("fastest", 'f', "run with the fastest possible configuration (--nowrap & --notabspaces)")
+	noBubble := boolFlag("no-bubble", 'B', "do not draw the speech bubble")
+	bubbleColour := stringFlag("bubble-color", 0, "", "the colour of the speech bubble & info box borders, as #rrggbb, 0-255, a name (e.g. red), or auto to match the pokemon", "colour")
+	textColour := stringFlag("text-color", 0, "", "the colour of the text in the speech bubble, as #rrggbb, 0-255 or a name (e.g. red)", "colour")
 
 	// info box options
-	japaneseName := getopt.BoolLong("japanese-name", 'j', "print the japanese name in the info box")
-	showId := getopt.BoolLong("id-info", 'I', "print the pokemon ID in the info box")
-	noCategoryInfo := getopt.BoolLong("no-category-info", 'C', "do not print pokemon category information in the info box")
-	drawInfoBorder := getopt.BoolLong("info-border", 'b', "draw a border around the info box")
+	japaneseName := boolFlag("japanese-name", 'j', "print the japanese name in the info box")
+	showId := boolFlag("id-info", 'I', "print the pokemon ID in the info box")
+	dexInfo := boolFlag("dex-info", 'D', "print the national dex number, types, generation and form in the info box")
+	noCategoryInfo := boolFlag("no-category-info", 'C', "do not print pokemon category information in the info box")
+	drawInfoBorder := boolFlag("info-border", 'b', "draw a border around the info box")
 
 	// other option
-	unicodeBorders := getopt.BoolLong("unicode-borders", 'u', "use unicode characters to draw the border around the speech box (and info box if --info-border is enabled)")
-	flipPokemon := getopt.BoolLong("flip", 'F', "flip the pokemon horizontally (face right instead of left)")
-
-	getopt.Parse()
+	unicodeBorders := boolFlag("unicode-borders", 'u', "use unicode characters to draw the border around the speech box (and info box if --info-border is enabled)")
+	borderStyle := stringFlag("border-style", 0, "", "the style of the border around the speech box and info box, one of "+strings.Join(pokesay.BorderStyleNames(), ", ")+", or a style from the config file (overrides --unicode-borders)", "style")
+	flip := stringFlag("flip", 'F', "", "flip the pokemon horizontally (face right instead of left), or with --flip=v upside down, or --flip=hv both ways", "h|v|hv")
+	optionalFlag("flip")
+	scale := stringFlag("scale", 0, "1", "resize the pokemon by a factor, e.g. 0.5 to halve it or 2 to double it", "factor")
+	filter := stringFlag("filter", 0, "", "change the colours of the pokemon, one of "+strings.Join(pokesay.Filters, ", "), "filter")
+	maxHeight := intFlag("max-height", 0, 0, "shrink the pokemon to fit in N lines (0 for no limit)", "N")
+	evolutions := boolFlag("evolutions", 0, "print the evolution line of the pokemon, side by side with arrows between them")
+
+	// animation options
+	animate := stringFlag("animate", 0, "", "redraw the pokemon in place until a key is pressed, cycling through its sprites (variants) or random pokemon (random)", "variants|random")
+	optionalFlag("animate")
+	interval := durationFlag("interval", 0, time.Second, "the time between the frames of --animate", "duration")
+	frames := intFlag("frames", 0, 0, "stop --animate after N frames (0 to run until a key is pressed)", "N")
+
+	// cowsay compatibility
+	exportCow := stringFlag("export-cow", 0, "", "print the pokemon with a name or ID as a cowsay cowfile (for use with cowsay -f), and exit", "name|id")
+	cowfile := stringFlag("cowfile", 0, "", "print the sprite from a cowsay cowfile, instead of a pokemon", "file")
+
+	getopt.SetParameters("[message ... | - | command [parameters ...]]")
+	getopt.SetUsage(printUsage)
+	params := parseInterspersed(os.Args)
+	config := applyConfig(*configFpath)
+
+	bubbleColourANSI, err := pokesay.ParseColour(*bubbleColour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	textColourANSI, err := pokesay.ParseColour(*textColour)
+	if err != nil {
+		log.Fatal(err)
+	} else if textColourANSI == pokesay.AutoColour {
+		log.Fatalf("the text colour can't be %s", pokesay.AutoColour)
+	}
+	if *format != "" && !slices.Contains(pokesay.ListFormats, *format) {
+		log.Fatalf("invalid format '%s', expected one of: %s", *format, strings.Join(pokesay.ListFormats, ", "))
+	}
+	listColumns, err := pokesay.ParseColumns(*columns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if (getopt.IsSet("columns") || getopt.IsSet("sort")) && (!getopt.IsSet("list-names") || *format == "") {
+		log.Fatalf("--columns and --sort can only be used with --list-names --format, one of: %s", strings.Join(pokesay.ListFormats, ", "))
+	}
+	command, commandArgs, messageWords := "", []string{}, params
+	if matchCommand(params) != nil {
+		command, commandArgs, messageWords = params[0], params[1:], nil
+	} else if getopt.IsSet("list-names") && *listNames == "" && len(params) == 1 {
+		// optional values must be given like -l=chu, so also accept -l chu
+		*listNames, messageWords = params[0], nil
+	}
+	message := messageReader(messageWords, *messageFpath)
+	flipDirection := ""
+	if getopt.IsSet("flip") || *flip != "" {
+		if flipDirection, err = pokesay.ParseFlip(*flip); err != nil {
+			log.Fatal(err)
+		}
+	}
+	scaleFactor, err := pokesay.ParseScale(*scale)
+	if err != nil {
+		log.Fatal(err)
+	}
+	filterName, err := pokesay.ParseFilter(*filter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shinyProbability := 0.0
+	if *shinyOdds != "" {
+		if shinyProbability, err = pokesay.ParseShinyOdds(*shinyOdds); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *maxHeight < 0 {
+		log.Fatalf("invalid max height %d, expected 0 or more lines", *maxHeight)
+	}
+	animation := ""
+	if getopt.IsSet("animate") || *animate != "" {
+		if animation, err = pokesay.ParseAnimate(*animate, *name != "" || *id != ""); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *interval <= 0 {
+		log.Fatalf("invalid interval %s, expected a duration greater than 0, e.g. 500ms", *interval)
+	}
+	if *frames < 0 {
+		log.Fatalf("invalid number of frames %d, expected 0 or more", *frames)
+	}
+	boxChars := pokesay.DetermineBoxChars(*unicodeBorders)
+	if *borderStyle != "" {
+		if boxChars, err = pokesay.LoadBorderStyle(*borderStyle, config.Sections); err != nil {
+			log.Fatal(err)
+		}
+	}
 	var args pokesay.Args
 
 	if *fastest {
@@ -80,7 +242,15 @@ func parseFlags() pokesay.Args {
 			TabSpaces:   "    ",
 			NoTabSpaces: true,
 			BoxChars:    pokesay.DetermineBoxChars(false),
+			ExportCow:   *exportCow,
+			Cowfile:     *cowfile,
+			Packs:       *packs,
+			Message:     message,
+			Command:     command,
+			CommandArgs: commandArgs,
+			Format:      *format,
 			Help:        *help,
+			GenerateMan: *generateMan,
 			Verbose:     *verbose,
 		}
 	} else {
@@ -94,170 +264,465 @@ func parseFlags() pokesay.Args {
 			ListCategories: *listCategories,
 			ListNames:      getopt.GetCount("list-names") > 0,
 			ListNameToken:  *listNames,
+			ListColumns:    listColumns,
+			ListSort:       *sortColumn,
 			Category:       *category,
 			NameToken:      *name,
 			IDToken:        *id,
+			DexToken:       *dex,
+			ShinyOdds:      shinyProbability,
 			JapaneseName:   *japaneseName,
 			ShowID:         *showId,
-			BoxChars:       pokesay.DetermineBoxChars(*unicodeBorders),
+			DexInfo:        *dexInfo,
+			BoxChars:       boxChars,
+			BubbleColour:   bubbleColourANSI,
+			TextColour:     textColourANSI,
 			DrawInfoBorder: *drawInfoBorder,
-			FlipPokemon:    *flipPokemon,
+			Scale:          scaleFactor,
+			MaxHeight:      *maxHeight,
+			Filter:         filterName,
+			Flip:           flipDirection,
+			Evolutions:     *evolutions,
+			Animate:        animation,
+			Interval:       *interval,
+			Frames:         *frames,
+			ExportCow:      *exportCow,
+			Cowfile:        *cowfile,
+			Packs:          *packs,
+			Message:        message,
+			Command:        command,
+			CommandArgs:    commandArgs,
+			Format:         *format,
 			Help:           *help,
+			GenerateMan:    *generateMan,
 			Verbose:        *verbose,
 		}
 	}
 	return args
 }
 
-// runListCategories prints all available categories
-// - This reads a list of categories from the embedded filesystem
-// - prints the list of categories, and the total number of categories
-func runListCategories() {
-	categories := pokedex.ReadStructFromBytes[[]string](GOBCategoryKeys)
-	fmt.Printf("%s\n%d %s\n", strings.Join(categories, " "), len(categories), "total categories")
+// parseInterspersed parses the command line flags, allowing them to come before, after or in-between
+// the parameters (e.g. `pokesay list -f json names`), and returns the parameters.
+// Everything after a "--" is a parameter, even if it looks like a flag
+func parseInterspersed(argv []string) []string {
+	params := make([]string, 0)
+	for {
+		getopt.CommandLine.Parse(argv)
+		rest := getopt.Args()
+		if getopt.CommandLine.State() == getopt.DashDash {
+			return append(params, rest...)
+		}
+		if len(rest) == 0 {
+			return params
+		}
+		params = append(params, rest[0])
+		argv = append([]string{argv[0]}, rest[1:]...)
+	}
 }
 
-// runListNames prints all available pokemon names
-// - This reads a struct of {name -> metadata indexes} from the embedded filesystem
-// - prints all the keys of the struct, and the total number of names
-func runListNames(token string) {
-	t := timer.NewTimer("runListNames", true)
-
-	pokedex.ReadStructFromBytes[map[string][]int](GOBAllNames)
-	namesSorted := pokedex.GatherMapKeys(pokedex.ReadStructFromBytes[map[string][]int](GOBAllNames))
-	t.Mark("read metadata")
+// messageReader returns the reader of the message to print in the speech bubble
+// - the message parameters joined with spaces, e.g. `pokesay hello world` (like cowsay)
+// - the --file, if given
+// - stdin, when the only parameter is "-", or there are no parameters and stdin isn't a terminal (e.g. `echo hi | pokesay`)
+//
+// When stdin is a terminal and no message was given, nil is returned so that pokesay doesn't wait for input
+func messageReader(words []string, fpath string) io.Reader {
+	if fpath != "" {
+		if len(words) > 0 {
+			log.Fatal("the message can be given as parameters or with --file, but not both")
+		}
+		f, err := os.Open(fpath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return f
+	}
+	if slices.Contains(words, "-") {
+		if len(words) > 1 {
+			log.Fatal("- reads the message from stdin, and can't be given with other message parameters")
+		}
+		return os.Stdin
+	}
+	if len(words) > 0 {
+		return strings.NewReader(strings.Join(words, " "))
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	return os.Stdin
+}
 
-	s := make(map[string]map[string]string)
-	exit := false
-	for i, name := range namesSorted {
-		metadata := pokedex.ReadMetadataFromEmbedded(GOBCowNames, pokedex.MetadataFpath(MetadataRoot, i))
+// applyConfig sets the value of every option that wasn't given on the command line,
+// from its POKESAY_* environment variable, or else from the config file.
+// This gives the precedence: command line flags > environment variables > config file > defaults
+// - the config file is read from --config, or $XDG_CONFIG_HOME/pokesay/config.toml if it exists
+// - config keys are the long option names, e.g. `width = 60`, `japanese-name = true`
+func applyConfig(configFpath string) pokesay.Config {
+	missingOK := configFpath == ""
+	if missingOK {
+		configFpath = pokesay.ConfigFpath()
+	}
+	config, err := pokesay.ReadConfig(configFpath, missingOK)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-		entries := make(map[string]string, 0)
+	skip := map[string]bool{"help": true, "generate-man": true, "export-cow": true, "config": true, "file": true, "list-names": true, "list-categories": true}
+	known := make(map[string]bool)
 
-		for _, entry := range metadata.Entries {
-			k := fmt.Sprintf("%04d.%04d", i, entry.EntryIndex)
-			entries[k] = strings.Join(entry.Categories, ", ")
+	getopt.VisitAll(func(opt getopt.Option) {
+		name := opt.LongName()
+		known[name] = true
+		if name == "" || skip[name] || opt.Seen() {
+			return
 		}
-		if token != "" && token == name {
-			json, _ := json.MarshalIndent(map[string]map[string]string{name: entries}, "", strings.Repeat(" ", 2))
-			fmt.Fprintln(os.Stdout, string(json))
-			exit = true
-			break
+		source := configFpath
+		value, ok := os.LookupEnv(pokesay.EnvKey(name))
+		if ok {
+			source = pokesay.EnvKey(name)
+		} else if value, ok = config.Options[name]; !ok {
+			return
 		}
-		s[name] = entries
+		if err := opt.Value().Set(value, opt); err != nil {
+			log.Fatalf("invalid value '%s' for %s in %s: %s", value, name, source, err)
+		}
+	})
+	for name := range config.Options {
+		if !known[name] || skip[name] {
+			log.Fatalf("unknown option '%s' in %s", name, configFpath)
+		}
+	}
+	timer.DebugTimer.Mark("apply config")
+
+	return config
+}
+
+// loadPokedex loads the embedded pokemon, and any extra packs that have been requested
+// - packs found in the directories listed in $POKESAY_PACKS are loaded first, then those given via --pack
+// - all packs are merged into a single set of metadata indexes, after the embedded pokemon
+func loadPokedex(args pokesay.Args) *pokesay.Pokedex {
+	dex, err := pokesay.NewPokedex(GOBAssets, AssetsRoot)
+	pokedex.Check(err)
+
+	dirpaths := pokedex.FindPackDirs(filepath.SplitList(os.Getenv("POKESAY_PACKS")))
+	pokedex.Check(dex.LoadPackDirs(append(dirpaths, args.Packs...)))
+	timer.DebugTimer.Mark("load packs")
+
+	return dex
+}
+
+// runGenerateMan prints the man page, generated from the registered flags, commands & categories
+func runGenerateMan() {
+	pokesay.WriteManPage(os.Stdout, pokesay.ManPage{
+		Version:    Version,
+		Date:       time.Now().Format("January 2006"),
+		Flags:      flagInfos(),
+		Commands:   commandInfos(),
+		Categories: Dex.Categories(),
+	})
+}
+
+// runListCategories prints all available categories
+// - This reads a list of categories from each pack
+// - prints the list of categories, and the total number of categories
+func runListCategories() {
+	categories := Dex.Categories()
+	fmt.Printf("%s\n%d %s\n", strings.Join(categories, " "), len(categories), "total categories")
+}
+
+// runListNames prints all pokemon sprites, as the JSON {name -> {ID -> categories}} map by default,
+// or the sorted --columns of each sprite with --format
+// - This searches the struct of {name -> metadata indexes} of each pack for names that contain the token
+// - reads the metadata files of only the matching pokemon
+// - filters the sprites by the --category expression, and sorts them
+func runListNames(args pokesay.Args) {
+	choices, err := Dex.ChoicesMatching(args.ListNameToken)
+	if err != nil {
+		log.Fatal(err)
+	}
+	timer.DebugTimer.Mark("read metadata")
+
+	filter, err := pokesay.ParseCategoryFilter(args.Category)
+	if err != nil {
+		log.Fatal(err)
 	}
-	t.Mark("read metadata")
-	if exit {
+	choices = filter.Filter(choices)
+	if args.Format == "" {
+		printJSON(Dex.NameMap(choices))
 		return
 	}
-	json, _ := json.MarshalIndent(s, "", strings.Repeat(" ", 2))
-	fmt.Fprintln(os.Stdout, string(json))
+	if err := pokesay.SortChoices(choices, args.ListSort); err != nil {
+		log.Fatal(err)
+	}
+	if err := pokesay.WriteList(os.Stdout, args.Format, args.ListColumns, choices); err != nil {
+		log.Fatal(err)
+	}
 }
 
 // GenerateNames returns a list of names to print
+// - If the roll of --shiny-odds made the pokemon shiny, the name has a sparkle after it
 // - If the japanese name flag is set, it returns both the english and japanese names
 // - Otherwise, it returns just the english name
-func GenerateNames(metadata pokedex.PokemonMetadata, args pokesay.Args, final pokedex.PokemonEntryMapping) []string {
-	nameParts := []string{metadata.Name}
+func GenerateNames(choice pokesay.Choice, args pokesay.Args, rolledShiny bool) []string {
+	nameParts := []string{choice.Metadata.Name}
+	if rolledShiny {
+		nameParts[0] += " " + pokesay.ShinyMarker
+	}
 	if args.JapaneseName {
-		nameParts = append(nameParts, fmt.Sprintf("%s (%s)", metadata.JapaneseName, metadata.JapanesePhonetic))
+		nameParts = append(nameParts, fmt.Sprintf("%s (%s)", choice.Metadata.JapaneseName, choice.Metadata.JapanesePhonetic))
 	}
 	if args.ShowID {
-		nameParts = append(nameParts, fmt.Sprintf("%s.%04d", metadata.Idx, final.EntryIndex))
+		nameParts = append(nameParts, choice.ID)
+	}
+	if args.DexInfo {
+		nameParts = append(nameParts, GenerateDexInfo(choice)...)
 	}
 	return nameParts
 }
 
-// runPrintByName prints a pokemon matched by a name
-// The name must match the lowercase name of the pokemon (TODO: improve this behaviour)
-// - This reads a struct of {name -> metadata indexes} from the embedded filesystem
-// - It matches the name to a metadata index, loads the corresponding metadata file, and then chooses a random entry
-// - Finally, it prints the pokemon
-func runPrintByName(args pokesay.Args) {
-	names := pokedex.ReadStructFromBytes[map[string][]int](GOBAllNames)
-	timer.DebugTimer.Mark("read name struct")
+// GenerateDexInfo returns the national dex number, types, generation and form of a pokemon, e.g.
+// #0006 | fire/flying | gen 1 | mega
+// Any information that isn't in the pokemon's metadata is skipped
+func GenerateDexInfo(choice pokesay.Choice) []string {
+	info := make([]string, 0)
+	if choice.Metadata.DexNumber > 0 {
+		info = append(info, fmt.Sprintf("#%04d", choice.Metadata.DexNumber))
+	}
+	if len(choice.Metadata.Types) > 0 {
+		info = append(info, strings.ToLower(strings.Join(choice.Metadata.Types, "/")))
+	}
+	if choice.Metadata.Generation > 0 {
+		info = append(info, fmt.Sprintf("gen %d", choice.Metadata.Generation))
+	}
+	for _, category := range choice.Entry.Categories {
+		if form, ok := strings.CutPrefix(category, "form:"); ok {
+			info = append(info, form)
+		}
+	}
+	return info
+}
 
-	metadata, final := pokesay.ChooseByName(names, args.NameToken, GOBCowNames, MetadataRoot)
+// pathCategories returns the categories that come from the cowfile path (e.g. small/gen8/shiny),
+// skipping those from the pokemon data (e.g. type:fire), which are shown via --dex-info instead
+func pathCategories(categories []string) []string {
+	filtered := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if !strings.Contains(category, ":") {
+			filtered = append(filtered, category)
+		}
+	}
+	return filtered
+}
 
-	pNames := GenerateNames(metadata, args, final)
+// printChoice prints a chosen pokemon, along with the text from STDIN
+func printChoice(args pokesay.Args, choice pokesay.Choice) {
+	choice, rolledShiny := rollShiny(args, choice)
+	names := GenerateNames(choice, args, rolledShiny)
 	timer.DebugTimer.Mark("generate names")
 
-	pokesay.Print(args, final.EntryIndex, pNames, final.Categories, GOBCowData)
+	if args.Evolutions {
+		if lines := Dex.Evolutions(choice); len(lines) > 0 {
+			timer.DebugTimer.Mark("find evolutions")
+			printEvolutions(args, choice, lines, names)
+			return
+		}
+	}
+	err := pokesay.Print(args, choice.Entry.EntryIndex, names, pathCategories(choice.Entry.Categories), choice.Pack.Pokedex)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-// runPrintByID prints a pokemon corresponding to a specific ID
-// - This reads a list of names from the embedded filesystem
-// - It finds the name at alphabetical index `IDToken`
-// - It matches the name to a metadata index, loads the corresponding metadata file, and then chooses a random entry
-// - Finally, it prints the pokemon
-func runPrintByID(args pokesay.Args) {
-	idxs := strings.Split(args.IDToken, ".")
-
-	idx, _ := strconv.Atoi(idxs[0])
-	subIdx, _ := strconv.Atoi(idxs[1])
+// printEvolutions prints the evolution lines of a chosen pokemon, e.g. charmander -> charmeleon -> charizard,
+// with the pokemon of each line side by side, and the text from STDIN above the first line
+func printEvolutions(args pokesay.Args, choice pokesay.Choice, lines [][]pokesay.Choice, names []string) {
+	// keep anything after the name, e.g. the shiny marker
+	suffix := strings.TrimPrefix(names[0], choice.Metadata.Name)
+	for i, line := range lines {
+		sprites, lineNames := make([][]byte, len(line)), make([]string, len(line))
+		for j, evolution := range line {
+			sprite, err := Dex.Sprite(evolution)
+			if err != nil {
+				log.Fatal(err)
+			}
+			sprites[j], lineNames[j] = sprite, evolution.Metadata.Name
+		}
+		names[0] = strings.Join(lineNames, " "+args.BoxChars.RightArrow+" ") + suffix
+		if i > 0 {
+			args.Message = nil
+		}
+		pokesay.PrintSprites(args, sprites, names, pathCategories(choice.Entry.Categories))
+	}
+}
 
-	timer.DebugTimer.Mark("format IDs")
+// rollShiny chooses whether a pokemon is shiny with --shiny-odds, by swapping its sprite for the shiny or regular version,
+// and returns true if the roll made it shiny. Sprites that were chosen by ID, or with the shiny or regular categories,
+// are kept as they are without a roll
+func rollShiny(args pokesay.Args, choice pokesay.Choice) (pokesay.Choice, bool) {
+	if args.ShinyOdds == 0 || args.IDToken != "" {
+		return choice, false
+	}
+	if strings.Contains(args.Category, pokesay.CategoryShiny) || strings.Contains(args.Category, pokesay.CategoryRegular) {
+		return choice, false
+	}
+	choice = Dex.RollShiny(choice, args.ShinyOdds)
+	timer.DebugTimer.Mark("roll shiny")
+	return choice, pokesay.IsShiny(choice)
+}
 
-	metadata, final, err := pokesay.ChooseByIndex(idx, subIdx, GOBCowNames, MetadataRoot)
+// runExportCow prints the pokemon with a name or ID as a cowsay cowfile
+func runExportCow(args pokesay.Args) {
+	choice := chooseByToken(args, args.ExportCow)
+	comment := fmt.Sprintf("%s (%s), exported by pokesay", choice.Metadata.Name, choice.ID)
+	sprite, err := Dex.Sprite(choice)
 	if err != nil {
 		log.Fatal(err)
 	}
+	os.Stdout.Write(pokesay.ExportCowfile(sprite, comment))
+}
 
-	pokesay.Print(args, subIdx, GenerateNames(metadata, args, final), final.Categories, GOBCowData)
+// runPrintCowfile prints the sprite from a cowsay cowfile, named after the file. The cowfile draws its own tether
+// to the speech bubble (with $thoughts), and it has no categories
+func runPrintCowfile(args pokesay.Args) {
+	data, err := os.ReadFile(args.Cowfile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sprite, err := pokesay.ParseCowfile(data, args.BoxChars.BalloonString)
+	if err != nil {
+		log.Fatalf("%s: %s", args.Cowfile, err)
+	}
+	args.NoTether, args.NoCategoryInfo = true, true
+	name := strings.TrimSuffix(filepath.Base(args.Cowfile), filepath.Ext(args.Cowfile))
+	pokesay.PrintSprite(args, sprite, []string{name}, []string{})
 }
 
-// runPrintByCategory prints a pokemon matched by a category
-// - This loads a GOB file containing a pokemon "category" search struct from the embedded filesystem
-// - It chooses a random category file from the corresponding category directory
-// - It reads the category file and chooses a random pokemon from the category
-//   - # TODO: as each category dir contains files with a singular entry of {metadata index/entry index},
-//   - # this means that pokemon that are in the same category multiple times will be chosen more often
-//
-// - It reads the metadata file of the chosen pokemon and chooses the corresponding entry from the category search
-// - Finally, it prints the pokemon
-func runPrintByCategory(args pokesay.Args) {
-	dirPath := pokedex.CategoryDirpath(CategoryRoot, args.Category)
-	dir, _ := GOBCategories.ReadDir(dirPath)
-	metadata, final := pokesay.ChooseByCategory(args.Category, dir, GOBCategories, CategoryRoot, GOBCowNames, MetadataRoot)
-
-	pokesay.Print(args, final.EntryIndex, GenerateNames(metadata, args, final), final.Categories, GOBCowData)
+// runPrint prints a pokemon chosen by the selection flags, or a random pokemon if there are none.
+// A --cowfile isn't in the library, so it is printed on its own
+func runPrint(args pokesay.Args) {
+	if args.Cowfile != "" {
+		runPrintCowfile(args)
+		return
+	}
+	printChoice(args, chooseChoice(args))
 }
 
-// runPrintByNameAndCategory prints a pokemon matched by a name and category
-// - This reads a struct of {name -> metadata indexes} from the embedded filesystem
-// - It matches the name to a metadata index, loads the corresponding metadata file, and then randomly chooses an entry that matches the category
-// - Finally, it prints the pokemon
-func runPrintByNameAndCategory(args pokesay.Args) {
-	names := pokedex.ReadStructFromBytes[map[string][]int](GOBAllNames)
-	timer.DebugTimer.Mark("read name struct")
+// chooseChoice chooses a pokemon with the selection flags, or a random pokemon if there are none
+//   - name & category: this chooses a pack that contains the name, and reads its struct of {name -> metadata indexes}.
+//     It matches the name to a metadata index, loads the corresponding metadata file, and then randomly chooses an entry that matches the category
+//   - name: as above, and then chooses a random entry.
+//     The name must match the lowercase name of the pokemon (TODO: improve this behaviour)
+//   - ID: stable IDs (e.g. pikachu/gen8/shiny) are looked up in the ID struct of each pack.
+//     Numeric IDs (e.g. 0025.0123) are looked up in the legacy ID struct first, and then by index,
+//     and metadata indexes (e.g. 0025) choose a random entry of the pokemon.
+//     If the ID doesn't match a pokemon, the nearest valid IDs are printed instead
+//   - dex: this parses the number/range, and finds the metadata indexes of all pokemon in the range.
+//     It chooses a random pokemon from those indexes, and then chooses a random entry
+//   - category: this chooses a pack that contains the category, weighted by the number of category files in each pack.
+//     It chooses a random category file from the corresponding category directory, reads it and chooses a random pokemon from the category.
+//     As each category dir contains files with a singular entry of {metadata index/entry index},
+//     pokemon that are in the same category multiple times will be chosen more often (TODO).
+//     It then reads the metadata file of the chosen pokemon and chooses the corresponding entry from the category search
+//   - random: this generates a random number between 0 and the total number of pokemon in all packs,
+//     finds the pack containing that index, reads the metadata file at `<index>.metadata`, and chooses a random entry
+func chooseChoice(args pokesay.Args) pokesay.Choice {
+	var choice pokesay.Choice
+	var err error
+	switch {
+	case args.NameToken != "" && args.Category != "":
+		choice, err = Dex.ByNameAndCategory(args.NameToken, args.Category)
+	case args.NameToken != "":
+		choice, err = Dex.ByName(args.NameToken)
+	case args.IDToken != "":
+		choice, err = Dex.ByID(args.IDToken)
+	case args.DexToken != "":
+		lo, hi, rangeErr := pokesay.ParseDexRange(args.DexToken)
+		if rangeErr != nil {
+			log.Fatal(rangeErr)
+		}
+		choice, err = Dex.ByDex(lo, hi)
+	case args.Category != "":
+		choice, err = Dex.ByCategory(args.Category)
+	default:
+		choice, err = Dex.Random()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return choice
+}
 
-	metadata, final := pokesay.ChooseByNameAndCategory(names, args.NameToken, GOBCowNames, MetadataRoot, args.Category)
+// runAnimate redraws the pokemon in place (with the same message) every --interval, until a key is pressed,
+// ctrl+c is pressed, or there have been --frames frames
+// - variants: the frames cycle through all sprites of the chosen pokemon, e.g. regular/shiny & gen7x/gen8
+// - random: every frame is a new pokemon, chosen with the selection flags (e.g. a random pokemon from --category)
+//
+// Key presses can only be read when stdin is a terminal, which is put in raw mode so that a single key stops the animation
+func runAnimate(args pokesay.Args) {
+	if args.Cowfile != "" {
+		log.Fatal("--animate can't be used with --cowfile")
+	}
+	message := ""
+	if args.Message != nil {
+		data, err := io.ReadAll(args.Message)
+		if err != nil {
+			log.Fatal(err)
+		}
+		message = string(data)
+	}
 
-	pokesay.Print(args, final.EntryIndex, GenerateNames(metadata, args, final), final.Categories, GOBCowData)
-}
+	choice := chooseChoice(args)
+	sprites := Dex.Sprites(choice)
+	variant := slices.IndexFunc(sprites, func(sprite pokesay.Choice) bool { return sprite.ID == choice.ID })
+	next := func() pokesay.Choice {
+		if args.Animate == pokesay.AnimateRandom {
+			return chooseChoice(args)
+		}
+		variant = (variant + 1) % len(sprites)
+		return sprites[variant]
+	}
 
-// runPrintRandom prints a random pokemon
-// - This loads a specific GOB file from the embedded filesystem that contains the number of pokemon
-// - generates a random number between 0 and the number of pokemon
-// - reads the metadata file of at `<index>.metadata` as a PokemonMetadata struct
-// - chooses a random entry from the metadata file
-// - finally prints the pokemon
-func runPrintRandom(args pokesay.Args) {
-	choice := pokesay.RandomInt(pokedex.ReadIntFromBytes(GOBTotal))
-	timer.DebugTimer.Mark("choose index")
-
-	metadata := pokedex.ReadMetadataFromEmbedded(
-		GOBCowNames,
-		pokedex.MetadataFpath(MetadataRoot, choice),
-	)
-
-	final := metadata.Entries[pokesay.RandomInt(len(metadata.Entries))]
-	timer.DebugTimer.Mark("choose entry")
-
-	names := GenerateNames(metadata, args, final)
-	timer.DebugTimer.Mark("generate names")
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	rawMode := false
+	if in := int(os.Stdin.Fd()); term.IsTerminal(in) {
+		if state, err := term.MakeRaw(in); err == nil {
+			rawMode = true
+			defer term.Restore(in, state)
+			go func() {
+				os.Stdin.Read(make([]byte, 16))
+				stop <- os.Interrupt
+			}()
+		}
+	}
+	// hide the cursor while animating
+	fmt.Print("\x1b[?25l")
+	defer fmt.Print("\x1b[?25h")
+
+	ticker := time.NewTicker(args.Interval)
+	defer ticker.Stop()
+	lines := 0
+	for frame := 1; ; frame++ {
+		var buf strings.Builder
+		args.Writer = &buf
+		if args.Message != nil {
+			args.Message = strings.NewReader(message)
+		}
+		printChoice(args, choice)
 
-	pokesay.Print(args, final.EntryIndex, names, final.Categories, GOBCowData)
+		var redraw string
+		redraw, lines = pokesay.RedrawFrame(buf.String(), lines, rawMode)
+		fmt.Print(redraw)
+		if args.Frames > 0 && frame >= args.Frames {
+			return
+		}
+		select {
+		case <-ticker.C:
+			choice = next()
+		case <-stop:
+			return
+		}
+	}
 }
 
 func main() {
@@ -268,28 +733,29 @@ func main() {
 
 	// if the -h/--help flag is set, print usage and exit
 	if args.Help {
-		getopt.Usage()
+		printUsage()
 		return
 	}
 	if args.Verbose {
 		fmt.Println("Verbose output enabled")
 		timer.DEBUG = true
 	}
-
-	if args.ListCategories {
+	Dex = loadPokedex(args)
+
+	if args.GenerateMan {
+		runGenerateMan()
+	} else if args.ExportCow != "" {
+		runExportCow(args)
+	} else if command := findCommand(args.Command); command != nil {
+		command.Run(args)
+	} else if args.ListCategories {
 		runListCategories()
 	} else if args.ListNames {
-		runListNames(args.ListNameToken)
-	} else if args.NameToken != "" && args.Category != "" {
-		runPrintByNameAndCategory(args)
-	} else if args.NameToken != "" {
-		runPrintByName(args)
-	} else if args.IDToken != "" {
-		runPrintByID(args)
-	} else if args.Category != "" {
-		runPrintByCategory(args)
+		runListNames(args)
+	} else if args.Animate != "" {
+		runAnimate(args)
 	} else {
-		runPrintRandom(args)
+		runPrint(args)
 	}
 
 	timer.DebugTimer.Mark("finish")
@@ -18,49 +18,70 @@ func normaliseRelativeDir(dirPath string) string {
 }
 
 type PokedexArgs struct {
-	FromDir           string
-	FromMetadataFname string
-	ToDir             string
-	Debug             bool
-	ToDataSubDir      string
-	ToMetadataSubDir  string
-	ToTotalFname      string
+	FromDir             string
+	FromMetadataFname   string
+	FromTypesFname      string
+	FromEvolutionsFname string
+	ToDir               string
+	Debug               bool
+	ToDataSubDir        string
+	ToMetadataSubDir    string
+	ToTotalFname        string
+	LegacyIDsFname      string
 }
 
 type PokedexPaths struct {
-	EntryDirPath    string
-	MetadataDirPath string
-	TotalFpath      string
+	EntryDirPath     string
+	MetadataDirPath  string
+	CategoryDirPath  string
+	TotalFpath       string
+	NamesFpath       string
+	DexFpath         string
+	IDsFpath         string
+	LegacyIDsFpath   string
+	CategoryKeyFpath string
 }
 
 func NewPokedexPaths(args PokedexArgs) PokedexPaths {
 	return PokedexPaths{
-		EntryDirPath:    path.Join(args.ToDir, args.ToDataSubDir),
-		MetadataDirPath: path.Join(args.ToDir, args.ToMetadataSubDir),
-		TotalFpath:      path.Join(args.ToDir, args.ToTotalFname),
+		EntryDirPath:     path.Join(args.ToDir, args.ToDataSubDir),
+		MetadataDirPath:  path.Join(args.ToDir, args.ToMetadataSubDir),
+		CategoryDirPath:  path.Join(args.ToDir, "categories"),
+		TotalFpath:       path.Join(args.ToDir, args.ToTotalFname),
+		NamesFpath:       path.Join(args.ToDir, "names.txt"),
+		DexFpath:         path.Join(args.ToDir, "dex.txt"),
+		IDsFpath:         path.Join(args.ToDir, "ids.txt"),
+		LegacyIDsFpath:   path.Join(args.ToDir, "legacy_ids.txt"),
+		CategoryKeyFpath: path.Join(args.ToDir, "category_keys.txt"),
 	}
 }
 
 func parseArgs() PokedexArgs {
 	fromDir := flag.String("from", "/tmp/cows", "from dir")
 	fromMetadataFname := flag.String("fromMetadata", "/tmp/cows/pokemon.json", "metadata file")
+	fromTypesFname := flag.String("fromTypes", "", "types file (optional)")
+	fromEvolutionsFname := flag.String("fromEvolutions", "", "evolution chains file (optional)")
 	toDir := flag.String("to", "build/assets/", "to dir")
 
 	toDataSubDir := flag.String("toDataSubDir", "cows/", "dir to write all binary (image) data to")
 	toMetadataSubDir := flag.String("toMetadataSubDir", "metadata/", "dir to write all binary (metadata) data to")
 	toTotalFname := flag.String("toTotalFname", "total.txt", "file to write the number of available entries to")
+	legacyIDsFname := flag.String("legacyIDs", "", "the committed legacy ID text file, with the numeric IDs of previous releases, updated with the IDs of this build (default: the legacy_ids.txt in the to dir)")
 	debug := flag.Bool("debug", false, "show debug logs")
 
 	flag.Parse()
 
 	args := PokedexArgs{
-		FromDir:           normaliseRelativeDir(*fromDir),
-		FromMetadataFname: *fromMetadataFname,
-		ToDir:             normaliseRelativeDir(*toDir),
-		ToDataSubDir:      normaliseRelativeDir(*toDataSubDir),
-		ToMetadataSubDir:  normaliseRelativeDir(*toMetadataSubDir),
-		ToTotalFname:      *toTotalFname,
-		Debug:             *debug,
+		FromDir:             normaliseRelativeDir(*fromDir),
+		FromMetadataFname:   *fromMetadataFname,
+		FromTypesFname:      *fromTypesFname,
+		FromEvolutionsFname: *fromEvolutionsFname,
+		ToDir:               normaliseRelativeDir(*toDir),
+		ToDataSubDir:        normaliseRelativeDir(*toDataSubDir),
+		ToMetadataSubDir:    normaliseRelativeDir(*toMetadataSubDir),
+		ToTotalFname:        *toTotalFname,
+		LegacyIDsFname:      *legacyIDsFname,
+		Debug:               *debug,
 	}
 	if args.Debug {
 		fmt.Printf("%+v\n", args)
@@ -76,11 +97,18 @@ func mkDirs(dirPaths []string) {
 }
 
 // This function reads in the files given by the PokedexArgs, and generates the data that pokesay will use when running
+// - The "names" & "dex" structs
+//   - map each pokemon name/national dex number to the indexes of the corresponding metadata files
+//
+// - The "ids" & "legacy ids" structs
+//   - map the stable ID of each cowfile (e.g. pikachu/gen8/shiny) to its metadata & entry indexes
+//   - map the numeric IDs of this & all previous builds to stable IDs
+//
 // - The "category" struct
 //   - contains category information, and the index of the corresponding metadata file
 //
 // - The "metadata" files
-//   - named like 1.metadata, contains pokemon info like name, categories, japanese name
+//   - named like 1.metadata, contains pokemon info like name, categories, japanese name, evolutions
 //
 // - The "data" files
 //   - contain the pokemon as gzipped text
@@ -91,6 +119,15 @@ func main() {
 	args := parseArgs()
 	paths := NewPokedexPaths(args)
 
+	// read the legacy IDs of the previous releases, or of the previous build before they are overwritten
+	var previousIDs map[string]string
+	if args.LegacyIDsFname != "" {
+		previousIDs = pokedex.ReadLegacyIDTextFile(args.LegacyIDsFname)
+		fmt.Println("- Read", len(previousIDs), "legacy IDs from", args.LegacyIDsFname)
+	} else {
+		previousIDs = pokedex.ReadLegacyIDFile(paths.LegacyIDsFpath)
+	}
+
 	// ensure that the destination directories exist
 	mkDirs([]string{paths.EntryDirPath, paths.MetadataDirPath})
 
@@ -100,6 +137,18 @@ func main() {
 	// Read pokemon names
 	pokemonNames := pokedex.ReadNames(args.FromMetadataFname)
 	fmt.Println("- Read", len(pokemonNames), "pokemon names from", args.FromMetadataFname)
+	// Read types
+	if args.FromTypesFname != "" {
+		types := pokedex.ReadTypes(args.FromTypesFname)
+		pokedex.AddTypes(pokemonNames, types)
+		fmt.Println("- Read types of", len(types), "pokemon from", args.FromTypesFname)
+	}
+	// Read evolution chains
+	if args.FromEvolutionsFname != "" {
+		evolutions := pokedex.ReadEvolutions(args.FromEvolutionsFname)
+		pokedex.AddEvolutions(pokemonNames, evolutions)
+		fmt.Println("- Read", len(evolutions), "evolution chains from", args.FromEvolutionsFname)
+	}
 
 	nameTokens := pokedex.GatherMapKeys(pokemonNames)
 	sort.Strings(nameTokens)
@@ -113,6 +162,7 @@ func main() {
 	fmt.Println("- Writing metadata to file")
 	pokemonMetadata := make([]pokedex.PokemonMetadata, 0)
 	uniqueNames := make(map[string][]int)
+	dexNumbers := make(map[int][]int)
 	nameVariants := make(map[string][]string)
 
 	pbar := bin.NewProgressBar(len(pokemonNames))
@@ -123,6 +173,9 @@ func main() {
 		pokedex.WriteStructToFile(metadata, pokedex.MetadataFpath(paths.MetadataDirPath, i))
 		pokemonMetadata = append(pokemonMetadata, *metadata)
 		uniqueNames[name.Slug] = append(uniqueNames[name.Slug], i)
+		if name.DexNumber > 0 {
+			dexNumbers[name.DexNumber] = append(dexNumbers[name.DexNumber], i)
+		}
 		i++
 		pbar.Add(1)
 	}
@@ -148,18 +201,30 @@ func main() {
 		pbar.Add(1)
 	}
 
-	pokedex.WriteStructToFile(uniqueNames, "build/assets/names.txt")
+	pokedex.WriteStructToFile(uniqueNames, paths.NamesFpath)
+	pokedex.WriteStructToFile(dexNumbers, paths.DexFpath)
+	pokedex.WriteStructToFile(pokedex.CreateIDStruct(pokemonMetadata), paths.IDsFpath)
+	legacyIDs := pokedex.CreateLegacyIDStruct(pokemonMetadata, previousIDs)
+	pokedex.WriteStructToFile(legacyIDs, paths.LegacyIDsFpath)
+	if args.LegacyIDsFname != "" {
+		pokedex.WriteLegacyIDTextFile(legacyIDs, args.LegacyIDsFname)
+	}
 
 	// 2. Create the category struct using the cowfile paths, pokemon names and indexes
 	fmt.Println("\n- Writing categories to file")
-	categories := pokedex.CreateCategoryStruct(args.FromDir, pokemonMetadata, args.Debug)
-	pokedex.WriteStructToFile(categories, "build/assets/category_keys.txt")
+	categories := pokedex.CreateCategoryStruct(paths.CategoryDirPath, pokemonMetadata, args.Debug)
+	pokedex.WriteStructToFile(categories, paths.CategoryKeyFpath)
 
 	fmt.Println("- Writing total metadata to", paths.TotalFpath)
 	pokedex.WriteIntToFile(len(pokemonMetadata), paths.TotalFpath)
 
 	fmt.Println("✓ Complete! Indexed", len(cowfileFpaths), "total cowfiles")
-	fmt.Println("✓ Wrote names to", "build/assets/names.txt")
+	fmt.Println("✓ Wrote names to", paths.NamesFpath)
+	fmt.Println("✓ Wrote national dex numbers to", paths.DexFpath)
+	fmt.Println("✓ Wrote IDs to", paths.IDsFpath, "and", paths.LegacyIDsFpath)
+	if args.LegacyIDsFname != "" {
+		fmt.Println("✓ Wrote legacy IDs to", args.LegacyIDsFname)
+	}
 	fmt.Println("✓ Wrote gzipped metadata to", paths.MetadataDirPath)
 	fmt.Println("✓ Wrote gzipped cowfiles to", paths.EntryDirPath)
 	fmt.Println("✓ Wrote 'total' metadata to", paths.TotalFpath, len(pokemonMetadata))
@@ -0,0 +1,74 @@
+package pokedex
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// Pokedex is a filesystem of pokemon assets, and the directories within it that hold each type of asset.
+// The filesystem can be anything that implements fs.FS, e.g. the embedded assets (embed.FS),
+// a directory on disk (os.DirFS), or an in-memory filesystem for tests (fstest.MapFS)
+type Pokedex struct {
+	FS           fs.FS
+	CategoryRoot string // the root directory of the pokemon categories
+	MetadataRoot string // the root directory of the pokemon metadata
+	CowDataRoot  string // the root directory of the pokemon cow data
+}
+
+// NewPokedex returns a Pokedex for assets stored under root in fsys, in the same layout as build/assets
+func NewPokedex(fsys fs.FS, root string) Pokedex {
+	return Pokedex{
+		FS:           fsys,
+		CategoryRoot: path.Join(root, "categories"),
+		MetadataRoot: path.Join(root, "metadata"),
+		CowDataRoot:  path.Join(root, "cows"),
+	}
+}
+
+// ReadMetadata reads the metadata file <idx>.metadata
+func (p Pokedex) ReadMetadata(idx int) (PokemonMetadata, error) {
+	fpath := MetadataFpath(p.MetadataRoot, idx)
+	data, err := fs.ReadFile(p.FS, fpath)
+	if err != nil {
+		return PokemonMetadata{}, err
+	}
+	metadata, err := DecodeStruct[PokemonMetadata](data)
+	if err != nil {
+		return PokemonMetadata{}, fmt.Errorf("invalid metadata file %s: %w", fpath, err)
+	}
+	return metadata, nil
+}
+
+// ReadCow reads the gzipped cowfile <idx>.cow, and returns it decompressed
+func (p Pokedex) ReadCow(idx int) ([]byte, error) {
+	fpath := EntryFpath(p.CowDataRoot, idx)
+	data, err := fs.ReadFile(p.FS, fpath)
+	if err != nil {
+		return nil, err
+	}
+	cow, err := DecompressBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cowfile %s: %w", fpath, err)
+	}
+	return cow, nil
+}
+
+// ReadCategoryDir lists the category files of a category.
+// If the category doesn't exist, an empty list is returned
+func (p Pokedex) ReadCategoryDir(category string) []fs.DirEntry {
+	dir, _ := fs.ReadDir(p.FS, CategoryDirpath(p.CategoryRoot, category))
+	files := make([]fs.DirEntry, 0, len(dir))
+	for _, entry := range dir {
+		// skip the directories of nested categories, e.g. type/fire/ when listing "type"
+		if !entry.IsDir() {
+			files = append(files, entry)
+		}
+	}
+	return files
+}
+
+// ReadCategoryFile reads a category file, which contains "<metadata index>/<entry index>"
+func (p Pokedex) ReadCategoryFile(category string, fname string) ([]byte, error) {
+	return fs.ReadFile(p.FS, CategoryFpath(p.CategoryRoot, category, fname))
+}
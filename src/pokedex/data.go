@@ -6,16 +6,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 //	{
-//	  "name": { "eng": "Charizard", "chs": "喷火龙", "jpn": "リザードン", "jpn_ro": "Lizardon" }
-//	  "slug": { "eng": "charizard",                  "jpn": "riza-don",   "jpn_ro": "lizardon" }
+//	  "idx":   "006",
+//	  "name":  { "eng": "Charizard", "chs": "喷火龙", "jpn": "リザードン", "jpn_ro": "Lizardon" }
+//	  "slug":  { "eng": "charizard",                  "jpn": "riza-don",   "jpn_ro": "lizardon" }
+//	  "gen-7": { "forms": { "$": {...}, "mega-x": {...}, "mega-y": {...} } }
+//	  "gen-8": { "forms": { "$": {...}, "gmax": {...} } }
 //	}
 //
 // Out of all these names, we want the name.jpn, name.jpn_ro, slug.eng,
+// and the national dex number (idx) and the names of any forms.
+// The types aren't in this data, and are read from a separate file (see ReadTypes)
 type DataEntry struct {
+	Idx  string `json:"idx"`
 	Name struct {
 		Eng    string `json:"eng"`
 		Jpn    string `json:"jpn"`
@@ -26,6 +34,12 @@ type DataEntry struct {
 		Jpn    string `json:"jpn"`
 		Jpn_ro string `json:"jpn_ro"`
 	} `json:"slug"`
+	Gen7 struct {
+		Forms map[string]json.RawMessage `json:"forms"`
+	} `json:"gen-7"`
+	Gen8 struct {
+		Forms map[string]json.RawMessage `json:"forms"`
+	} `json:"gen-8"`
 }
 
 type PokemonName struct {
@@ -33,14 +47,74 @@ type PokemonName struct {
 	Japanese         string
 	JapanesePhonetic string
 	Slug             string
+	DexNumber        int
+	Types            []string
+	Generation       int
+	Forms            []string
+	Evolutions       [][]string
+}
+
+var (
+	// The last national dex number of each generation
+	generationEnds []int = []int{151, 251, 386, 493, 649, 721, 809, 905, 1025}
+	// Form names that are shortened in the pokesprite data
+	formNames map[string]string = map[string]string{
+		"alola":  "alolan",
+		"galar":  "galarian",
+		"hisui":  "hisuian",
+		"paldea": "paldean",
+	}
+)
+
+// Generation returns the generation that a national dex number was introduced in, or 0 if unknown
+func Generation(dexNumber int) int {
+	if dexNumber <= 0 {
+		return 0
+	}
+	for i, end := range generationEnds {
+		if dexNumber <= end {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// FormName returns the name of the form of a pokesprite form key, or of a cowfile name suffix
+// e.g. "alola" -> "alolan", "mega-x" -> "mega", "gmax" -> "gmax"
+func FormName(key string) string {
+	key = strings.SplitN(key, "-", 2)[0]
+	if name, ok := formNames[key]; ok {
+		return name
+	}
+	return key
+}
+
+// collectForms returns the sorted, unique form names of a pokemon, excluding the default form ("$")
+func collectForms(forms ...map[string]json.RawMessage) []string {
+	unique := make(map[string]bool)
+	for _, f := range forms {
+		for key := range f {
+			if key != "$" {
+				unique[FormName(key)] = true
+			}
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	return GatherMapKeys(unique)
 }
 
 func NewPokemonName(entry DataEntry) *PokemonName {
+	dexNumber, _ := strconv.Atoi(entry.Idx)
 	return &PokemonName{
 		English:          entry.Name.Eng,
 		Japanese:         entry.Name.Jpn,
 		JapanesePhonetic: entry.Slug.Jpn,
 		Slug:             entry.Slug.Eng,
+		DexNumber:        dexNumber,
+		Generation:       Generation(dexNumber),
+		Forms:            collectForms(entry.Gen7.Forms, entry.Gen8.Forms),
 	}
 }
 
@@ -67,3 +141,94 @@ func ReadNames(fpath string) map[string]PokemonName {
 	}
 	return entries
 }
+
+// TypesEntry is a line of the types file, with the types of a pokemon by its national dex number, e.g.
+//
+//	{"idx": "006", "types": ["fire", "flying"]}
+type TypesEntry struct {
+	Idx   string   `json:"idx"`
+	Types []string `json:"types"`
+}
+
+// ReadTypes reads the types file, and returns the primary (and secondary) types of each national dex number
+func ReadTypes(fpath string) map[int][]string {
+	istream, err := os.Open(fpath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer istream.Close()
+
+	types := make(map[int][]string)
+	scanner := bufio.NewScanner(istream)
+	for scanner.Scan() {
+		var entry TypesEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if dexNumber, err := strconv.Atoi(entry.Idx); err == nil {
+			types[dexNumber] = entry.Types
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return types
+}
+
+// AddTypes sets the types of every pokemon that has a national dex number in the types
+func AddTypes(names map[string]PokemonName, types map[int][]string) {
+	for key, name := range names {
+		if t, ok := types[name.DexNumber]; ok && name.DexNumber > 0 {
+			name.Types = t
+			names[key] = name
+		}
+	}
+}
+
+// Each line of the evolutions file is an evolution chain, as a list of evolution lines.
+// Each evolution line is the path from the first pokemon of the chain to one of its final
+// evolutions, by name slug, e.g.
+//
+//	[["charmander", "charmeleon", "charizard"]]
+//	[["wurmple", "silcoon", "beautifly"], ["wurmple", "cascoon", "dustox"]]
+//
+// Pokemon that don't evolve are a chain with a single line of 1 pokemon
+func ReadEvolutions(fpath string) [][][]string {
+	istream, err := os.Open(fpath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer istream.Close()
+
+	chains := make([][][]string, 0)
+	scanner := bufio.NewScanner(istream)
+	for scanner.Scan() {
+		var chain [][]string
+		if err := json.Unmarshal(scanner.Bytes(), &chain); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		chains = append(chains, chain)
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+	return chains
+}
+
+// AddEvolutions sets the evolution lines of every pokemon that is in an evolution chain.
+// A pokemon is only given the lines that it is in, e.g. vaporeon is given [eevee vaporeon],
+// but not the lines of the other eeveelutions. Lines of a single pokemon (that don't evolve) are skipped
+func AddEvolutions(names map[string]PokemonName, chains [][][]string) {
+	for key, name := range names {
+		for _, chain := range chains {
+			for _, line := range chain {
+				if len(line) > 1 && slices.Contains(line, name.Slug) {
+					name.Evolutions = append(name.Evolutions, line)
+				}
+			}
+		}
+		names[key] = name
+	}
+}
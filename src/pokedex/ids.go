@@ -0,0 +1,119 @@
+package pokedex
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EntryID returns the stable ID of a cowfile, using its path relative to the cowfile root dir.
+// The pokemon name comes first, followed by the dirs that the cowfile is in,
+// e.g. "gen8/shiny/pikachu.cow" -> "pikachu/gen8/shiny"
+//
+// Unlike the metadata & entry indexes, the ID doesn't change when the assets are rebuilt
+// with more (or fewer) cowfiles, so it is safe to save & share
+func EntryID(fpath string) string {
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(fpath, ".cow"), "/"), "/")
+	return strings.Join(append(parts[len(parts)-1:], parts[:len(parts)-1]...), "/")
+}
+
+// IndexID returns the numeric ID of a sprite, which is its metadata index and entry index, e.g. "0025.0123"
+func IndexID(idx int, entryIdx int) string {
+	return fmt.Sprintf("%04d.%04d", idx, entryIdx)
+}
+
+// CreateIDStruct creates the {stable ID -> [metadata index, entry index]} struct, used to find sprites by ID
+func CreateIDStruct(metadata []PokemonMetadata) map[string][2]int {
+	ids := make(map[string][2]int)
+	for i, m := range metadata {
+		for _, entry := range m.Entries {
+			ids[entry.ID] = [2]int{i, entry.EntryIndex}
+		}
+	}
+	return ids
+}
+
+// CreateLegacyIDStruct creates the {numeric ID -> stable ID} struct, so that numeric IDs that were
+// shared before a rebuild still point at the same sprites.
+// It contains the numeric IDs of this build, and those of all previous builds (from previous),
+// where the previous builds take priority, as their IDs were handed out first
+func CreateLegacyIDStruct(metadata []PokemonMetadata, previous map[string]string) map[string]string {
+	legacy := make(map[string]string)
+	for i, m := range metadata {
+		for _, entry := range m.Entries {
+			legacy[IndexID(i, entry.EntryIndex)] = entry.ID
+		}
+	}
+	for k, v := range previous {
+		legacy[k] = v
+	}
+	return legacy
+}
+
+// ReadLegacyIDFile reads the {numeric ID -> stable ID} struct of a previous build.
+// If the file doesn't exist (e.g. for the very first build), an empty struct is returned
+func ReadLegacyIDFile(fpath string) map[string]string {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return make(map[string]string)
+	}
+	return ReadStructFromBytes[map[string]string](data)
+}
+
+// The legacy ID text file is the copy of the {numeric ID -> stable ID} struct that is committed to the repo
+// (build/legacy_ids.txt), as the assets dir isn't. It has a "<numeric ID> <stable ID>" line per ID, sorted
+// by numeric ID, and comment lines starting with "#", e.g.
+//
+//	0000.0000 abomasnow/gen7x/regular
+//	0000.0001 abomasnow/gen7x/shiny
+const legacyIDTextHeader = `# The numeric IDs of every pokesay release, and the stable IDs that they point at,
+# as "<numeric ID> <stable ID>" lines.
+# This file is updated by build/scripts/build_assets.sh, commit it alongside each release
+`
+
+// ParseLegacyIDText parses the contents of a legacy ID text file
+func ParseLegacyIDText(data []byte) (map[string]string, error) {
+	legacy := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid legacy ID on line %d: '%s'", n, line)
+		}
+		legacy[fields[0]] = fields[1]
+	}
+	return legacy, scanner.Err()
+}
+
+// FormatLegacyIDText formats the {numeric ID -> stable ID} struct as the contents of a legacy ID text file
+func FormatLegacyIDText(legacy map[string]string) []byte {
+	var b bytes.Buffer
+	b.WriteString(legacyIDTextHeader)
+	for _, k := range GatherMapKeys(legacy) {
+		fmt.Fprintf(&b, "%s %s\n", k, legacy[k])
+	}
+	return b.Bytes()
+}
+
+// ReadLegacyIDTextFile reads a legacy ID text file.
+// If the file doesn't exist, an empty struct is returned
+func ReadLegacyIDTextFile(fpath string) map[string]string {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		return make(map[string]string)
+	}
+	legacy, err := ParseLegacyIDText(data)
+	Check(err)
+	return legacy
+}
+
+// WriteLegacyIDTextFile writes the {numeric ID -> stable ID} struct to a legacy ID text file
+func WriteLegacyIDTextFile(legacy map[string]string, fpath string) {
+	WriteBytesToFile(FormatLegacyIDText(legacy), fpath, false)
+}
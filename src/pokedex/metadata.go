@@ -1,7 +1,7 @@
 package pokedex
 
 import (
-	"embed"
+	"io/fs"
 	"os"
 
 	"github.com/tmck-code/pokesay/src/timer"
@@ -10,6 +10,7 @@ import (
 type PokemonEntryMapping struct {
 	EntryIndex int
 	Categories []string
+	ID         string // the stable ID of the sprite, e.g. pikachu/gen8/shiny
 }
 
 type PokemonMetadata struct {
@@ -17,24 +18,25 @@ type PokemonMetadata struct {
 	Name             string
 	JapaneseName     string
 	JapanesePhonetic string
+	DexNumber        int        // the national pokedex number
+	Types            []string   // the primary (and secondary) types
+	Generation       int        // the generation that the pokemon was introduced in
+	Forms            []string   // the names of any alternate forms, e.g. alolan, galarian, mega, gmax
+	Evolutions       [][]string // the evolution lines that the pokemon is in, by name slug, e.g. [[charmander charmeleon charizard]]
 	Entries          []PokemonEntryMapping
 }
 
-func NewMetadata(idx string, name string, japaneseName string, japanesePhonetic string, entryMap map[int][][]string) *PokemonMetadata {
-
-	entries := make([]PokemonEntryMapping, 0)
-
-	for idx, categories := range entryMap {
-		for _, category := range categories {
-			entries = append(entries, PokemonEntryMapping{idx, category})
-		}
-	}
-
+func NewMetadata(idx string, name PokemonName, entries []PokemonEntryMapping) *PokemonMetadata {
 	return &PokemonMetadata{
 		Idx:              idx,
-		Name:             name,
-		JapaneseName:     japaneseName,
-		JapanesePhonetic: japanesePhonetic,
+		Name:             name.English,
+		JapaneseName:     name.Japanese,
+		JapanesePhonetic: name.JapanesePhonetic,
+		DexNumber:        name.DexNumber,
+		Types:            name.Types,
+		Generation:       name.Generation,
+		Forms:            name.Forms,
+		Evolutions:       name.Evolutions,
 		Entries:          entries,
 	}
 }
@@ -54,8 +56,10 @@ func ReadMetadataFromFile(fpath string) PokemonMetadata {
 	return data
 }
 
-func ReadMetadataFromEmbedded(embeddedData embed.FS, fpath string) PokemonMetadata {
-	metadata, err := embeddedData.ReadFile(fpath)
+// ReadMetadataFromEmbedded reads a metadata file from a filesystem of assets.
+// Despite the name, this accepts any fs.FS (e.g. embed.FS, os.DirFS or fstest.MapFS)
+func ReadMetadataFromEmbedded(embeddedData fs.FS, fpath string) PokemonMetadata {
+	metadata, err := fs.ReadFile(embeddedData, fpath)
 	Check(err)
 	timer.DebugTimer.Mark("read embedded file")
 
@@ -0,0 +1,275 @@
+package pokedex
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A Pack is a set of pokesay assets, stored in the same layout as build/assets
+// - category_keys.txt, names.txt, dex.txt, ids.txt, legacy_ids.txt & total.txt
+// - cows/<n>.cow, metadata/<n>.metadata & categories/<category>/<n>.cat
+type Pack struct {
+	Pokedex
+	Name  string
+	Root  string
+	Total int
+
+	names        map[string][]int
+	dexNumbers   map[int][]int
+	ids          map[string][2]int
+	legacyIDs    map[string]string
+	categoryKeys []string
+}
+
+// NewPack reads the total, name index & category index of the pack stored under root in fsys,
+// and checks that it has a metadata dir, so that a broken pack is rejected when it is loaded.
+// The other indexes are only read when first needed, as they are only used by some lookups
+func NewPack(name string, fsys fs.FS, root string) (*Pack, error) {
+	total, err := fs.ReadFile(fsys, path.Join(root, "total.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read pack '%s': %w", name, err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(total)))
+	if err != nil {
+		return nil, fmt.Errorf("could not read pack '%s' total: %w", name, err)
+	}
+
+	pack := &Pack{
+		Pokedex: NewPokedex(fsys, root),
+		Name:    name,
+		Root:    root,
+		Total:   n,
+	}
+	if pack.names, err = readPackStruct[map[string][]int](fsys, path.Join(root, "names.txt")); err != nil {
+		return nil, fmt.Errorf("could not read pack '%s': %w", name, err)
+	}
+	if pack.categoryKeys, err = readPackStruct[[]string](fsys, path.Join(root, "category_keys.txt")); err != nil {
+		return nil, fmt.Errorf("could not read pack '%s': %w", name, err)
+	}
+	if info, err := fs.Stat(fsys, pack.MetadataRoot); err != nil {
+		return nil, fmt.Errorf("could not read pack '%s': %w", name, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("could not read pack '%s': %s is not a directory", name, pack.MetadataRoot)
+	}
+	return pack, nil
+}
+
+// readPackStruct reads a gob-encoded index of a pack
+func readPackStruct[T any](fsys fs.FS, fpath string) (T, error) {
+	data, err := fs.ReadFile(fsys, fpath)
+	if err != nil {
+		var empty T
+		return empty, err
+	}
+	d, err := DecodeStruct[T](data)
+	if err != nil {
+		return d, fmt.Errorf("invalid index %s: %w", fpath, err)
+	}
+	return d, nil
+}
+
+// Names returns the {name -> metadata indexes} struct of the pack
+func (pack *Pack) Names() map[string][]int {
+	return pack.names
+}
+
+// DexNumbers returns the {national dex number -> metadata indexes} struct of the pack.
+// Packs that were built without dex numbers return an empty struct
+func (pack *Pack) DexNumbers() map[int][]int {
+	if pack.dexNumbers == nil {
+		data, err := fs.ReadFile(pack.FS, path.Join(pack.Root, "dex.txt"))
+		if err != nil {
+			pack.dexNumbers = make(map[int][]int)
+		} else {
+			pack.dexNumbers = ReadStructFromBytes[map[int][]int](data)
+		}
+	}
+	return pack.dexNumbers
+}
+
+// IDs returns the {stable ID -> [metadata index, entry index]} struct of the pack.
+// Packs that were built without stable IDs return an empty struct
+func (pack *Pack) IDs() map[string][2]int {
+	if pack.ids == nil {
+		data, err := fs.ReadFile(pack.FS, path.Join(pack.Root, "ids.txt"))
+		if err != nil {
+			pack.ids = make(map[string][2]int)
+		} else {
+			pack.ids = ReadStructFromBytes[map[string][2]int](data)
+		}
+	}
+	return pack.ids
+}
+
+// LegacyIDs returns the {numeric ID -> stable ID} struct of the pack, which maps the numeric IDs of
+// this & all previous builds of the pack to stable IDs.
+// Packs that were built without stable IDs return an empty struct
+func (pack *Pack) LegacyIDs() map[string]string {
+	if pack.legacyIDs == nil {
+		data, err := fs.ReadFile(pack.FS, path.Join(pack.Root, "legacy_ids.txt"))
+		if err != nil {
+			pack.legacyIDs = make(map[string]string)
+		} else {
+			pack.legacyIDs = ReadStructFromBytes[map[string]string](data)
+		}
+	}
+	return pack.legacyIDs
+}
+
+// CategoryKeys returns the sorted list of categories in the pack
+func (pack *Pack) CategoryKeys() []string {
+	return pack.categoryKeys
+}
+
+// LoadPackDir reads a pack from a directory on disk
+func LoadPackDir(dirpath string) (*Pack, error) {
+	return NewPack(dirpath, os.DirFS(dirpath), ".")
+}
+
+func isPackDir(dirpath string) bool {
+	info, err := os.Stat(filepath.Join(dirpath, "total.txt"))
+	return err == nil && !info.IsDir()
+}
+
+// FindPackDirs searches a list of directories (e.g. from $POKESAY_PACKS) for packs.
+// Each directory can either be a pack itself, or contain packs as subdirectories
+func FindPackDirs(searchPath []string) []string {
+	dirpaths := make([]string, 0)
+	for _, dirpath := range searchPath {
+		if dirpath == "" {
+			continue
+		}
+		if isPackDir(dirpath) {
+			dirpaths = append(dirpaths, dirpath)
+			continue
+		}
+		entries, err := os.ReadDir(dirpath)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			subdir := filepath.Join(dirpath, entry.Name())
+			if entry.IsDir() && isPackDir(subdir) {
+				dirpaths = append(dirpaths, subdir)
+			}
+		}
+	}
+	return dirpaths
+}
+
+// ErrIndexNotFound is returned when there is no metadata file or entry at an index
+var ErrIndexNotFound = errors.New("could not find pokemon by index")
+
+// Packs is an ordered set of packs, merged into a single set of metadata indexes.
+// The metadata files of each pack are numbered after those of all the packs before it
+type Packs []*Pack
+
+// Total returns the number of metadata files across all packs
+func (packs Packs) Total() int {
+	total := 0
+	for _, pack := range packs {
+		total += pack.Total
+	}
+	return total
+}
+
+// Offset returns the merged metadata index of the first metadata file in pack
+func (packs Packs) Offset(pack *Pack) int {
+	offset := 0
+	for _, p := range packs {
+		if p == pack {
+			break
+		}
+		offset += p.Total
+	}
+	return offset
+}
+
+// Locate finds the pack containing the metadata file at merged index idx,
+// and returns the index of that file within the pack
+func (packs Packs) Locate(idx int) (*Pack, int, error) {
+	if idx >= 0 {
+		for _, pack := range packs {
+			if idx < pack.Total {
+				return pack, idx, nil
+			}
+			idx -= pack.Total
+		}
+	}
+	return nil, 0, ErrIndexNotFound
+}
+
+// Names merges the {name -> metadata indexes} structs of all packs, using merged indexes
+func (packs Packs) Names() map[string][]int {
+	if len(packs) == 1 {
+		return packs[0].Names()
+	}
+	names := make(map[string][]int)
+	offset := 0
+	for _, pack := range packs {
+		for name, idxs := range pack.Names() {
+			for _, idx := range idxs {
+				names[name] = append(names[name], idx+offset)
+			}
+		}
+		offset += pack.Total
+	}
+	return names
+}
+
+// FindID finds the pack containing the sprite with a stable ID, and returns the metadata & entry index
+// of the sprite within the pack. If more than one pack has the ID, the first pack wins
+func (packs Packs) FindID(id string) (*Pack, int, int, error) {
+	for _, pack := range packs {
+		if idxs, ok := pack.IDs()[id]; ok {
+			return pack, idxs[0], idxs[1], nil
+		}
+	}
+	return nil, 0, 0, fmt.Errorf("could not find pokemon by ID '%s'", id)
+}
+
+// DexRange returns the merged metadata indexes of all pokemon with a national dex number between lo and hi (inclusive),
+// in national dex order within each pack. Only the dex numbers that the packs have are visited, so the range can be
+// arbitrarily wide
+func (packs Packs) DexRange(lo int, hi int) []int {
+	idxs := make([]int, 0)
+	offset := 0
+	for _, pack := range packs {
+		dexNumbers := pack.DexNumbers()
+		inRange := make([]int, 0)
+		for n := range dexNumbers {
+			if lo <= n && n <= hi {
+				inRange = append(inRange, n)
+			}
+		}
+		slices.Sort(inRange)
+		for _, n := range inRange {
+			for _, idx := range dexNumbers[n] {
+				idxs = append(idxs, idx+offset)
+			}
+		}
+		offset += pack.Total
+	}
+	return idxs
+}
+
+// CategoryKeys returns the sorted, unique categories of all packs
+func (packs Packs) CategoryKeys() []string {
+	if len(packs) == 1 {
+		return packs[0].CategoryKeys()
+	}
+	unique := make(map[string]bool)
+	for _, pack := range packs {
+		for _, category := range pack.CategoryKeys() {
+			unique[category] = true
+		}
+	}
+	return GatherMapKeys(unique)
+}
@@ -4,10 +4,10 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
-	"embed"
 	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path"
@@ -30,12 +30,14 @@ func MetadataFpath(subdir string, idx int) string {
 	return path.Join(subdir, fmt.Sprintf("%d.metadata", idx))
 }
 
+// CategoryDirpath returns the directory of a category.
+// Categories like "type:fire" are stored in nested directories, e.g. type/fire/
 func CategoryDirpath(subdir string, cat string) string {
-	return path.Join(subdir, cat)
+	return path.Join(subdir, strings.ReplaceAll(cat, ":", "/"))
 }
 
 func CategoryFpath(subdir string, category string, fname string) string {
-	return path.Join(subdir, category, fname)
+	return path.Join(CategoryDirpath(subdir, category), fname)
 }
 
 func GatherMapKeys[T any](m map[string]T) []string {
@@ -55,6 +57,13 @@ func ReadStructFromBytes[T any](data []byte) T {
 	return d
 }
 
+// DecodeStruct decodes a gob-encoded struct, returning an error if the data is corrupt
+func DecodeStruct[T any](data []byte) (T, error) {
+	var d T
+	err := gob.NewDecoder(bytes.NewBuffer(data)).Decode(&d)
+	return d, err
+}
+
 func WriteStructToFile(obj interface{}, fpath string) {
 	ostream, err := os.Create(fpath)
 	Check(err)
@@ -117,49 +126,70 @@ func Compress(data []byte) []byte {
 }
 
 func Decompress(data []byte) []byte {
+	resB, err := DecompressBytes(data)
+	Check(err)
+
+	return resB
+}
+
+// DecompressBytes un-gzips data, returning an error if the data is corrupt
+func DecompressBytes(data []byte) ([]byte, error) {
 	buf := bytes.NewBuffer(data)
 
 	reader, err := gzip.NewReader(buf)
-	Check(err)
+	if err != nil {
+		return nil, err
+	}
 
 	var resB bytes.Buffer
 
-	_, err = resB.ReadFrom(reader)
-	Check(err)
-
-	return resB.Bytes()
+	if _, err = resB.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	return resB.Bytes(), nil
 }
 
 func CreateNameMetadata(idx string, key string, name PokemonName, rootDir string, fpaths []string) *PokemonMetadata {
-	entryCategories := make(map[int][][]string, 0)
+	entries := make([]PokemonEntryMapping, 0)
 	for i, fpath := range fpaths {
 		basename := strings.TrimPrefix(fpath, rootDir)
 		if strings.Contains(basename, "/"+strings.ToLower(name.Slug)+"-") || strings.Contains(basename, "/"+strings.ToLower(name.Slug)+".") {
 			data, err := os.ReadFile(fpath)
 			Check(err)
 			cats := createCategories(strings.TrimPrefix(fpath, rootDir), data)
-			entryCategories[i] = append(entryCategories[i], cats)
+			cats = append(cats, createDataCategories(name, basename)...)
+			entries = append(entries, PokemonEntryMapping{EntryIndex: i, Categories: cats, ID: EntryID(basename)})
 		}
 	}
-	return NewMetadata(
-		idx,
-		name.English,
-		name.Japanese,
-		name.JapanesePhonetic,
-		entryCategories,
-	)
+	return NewMetadata(idx, name, entries)
+}
+
+// createDataCategories creates the categories for an entry that come from the pokemon data, rather than the cowfile path,
+// e.g. "type:fire", "generation:1", and "form:mega" for a cowfile named like charizard-mega-x.cow
+func createDataCategories(name PokemonName, fpath string) []string {
+	cats := make([]string, 0)
+	for _, t := range name.Types {
+		cats = append(cats, "type:"+strings.ToLower(t))
+	}
+	if name.Generation > 0 {
+		cats = append(cats, fmt.Sprintf("generation:%d", name.Generation))
+	}
+	basename := strings.TrimSuffix(path.Base(fpath), ".cow")
+	if suffix, ok := strings.CutPrefix(basename, strings.ToLower(name.Slug)+"-"); ok {
+		cats = append(cats, "form:"+FormName(suffix))
+	}
+	return cats
 }
 
-func CreateCategoryStruct(rootDir string, metadata []PokemonMetadata, debug bool) []string {
+// CreateCategoryStruct writes a category file for every entry in every category to categoryDir,
+// e.g. <categoryDir>/small/04.cat, and returns the sorted list of unique categories
+func CreateCategoryStruct(categoryDir string, metadata []PokemonMetadata, debug bool) []string {
 	uniqueCategories := make(map[string]bool)
 	for i, m := range metadata {
 		for j, entry := range m.Entries {
 			for _, cat := range entry.Categories {
 				uniqueCategories[cat] = true
-				destDir := fmt.Sprintf(
-					"build/assets/categories/%s",
-					cat,
-				)
+				destDir := CategoryDirpath(categoryDir, cat)
 				os.MkdirAll(destDir, 0755)
 				WriteBytesToFile([]byte(fmt.Sprintf("%d/%d", i, j)), fmt.Sprintf("%s/%02d%s", destDir, i, ".cat"), false)
 			}
@@ -184,8 +214,9 @@ func sizeCategory(height int) string {
 	return "big"
 }
 
-func ReadPokemonCow(embeddedData embed.FS, fpath string) []byte {
-	d, err := embeddedData.ReadFile(fpath)
+// ReadPokemonCow reads a gzipped cowfile from a filesystem of assets, and returns it decompressed
+func ReadPokemonCow(embeddedData fs.FS, fpath string) []byte {
+	d, err := fs.ReadFile(embeddedData, fpath)
 	Check(err)
 
 	return Decompress(d)
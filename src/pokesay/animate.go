@@ -0,0 +1,46 @@
+package pokesay
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	AnimateVariants string = "variants" // cycle through all sprites of the chosen pokemon
+	AnimateRandom   string = "random"   // choose a new pokemon for every frame
+)
+
+// AnimateModes are the values of --animate
+var AnimateModes []string = []string{AnimateVariants, AnimateRandom}
+
+// ParseAnimate parses an --animate value. An empty value cycles through the sprites of the chosen
+// pokemon if one was chosen by name or ID, and chooses random pokemon otherwise
+func ParseAnimate(value string, chosen bool) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "":
+		if chosen {
+			return AnimateVariants, nil
+		}
+		return AnimateRandom, nil
+	case AnimateVariants:
+		return AnimateVariants, nil
+	case AnimateRandom:
+		return AnimateRandom, nil
+	}
+	return "", fmt.Errorf("invalid animation '%s', expected one of: %s", value, strings.Join(AnimateModes, ", "))
+}
+
+// RedrawFrame returns the text that replaces the previous frame of an animation (with previousLines lines)
+// with the next frame: the cursor is moved up to the start of the previous frame, and the rest of the
+// screen is cleared. It also returns the number of lines in the frame, for the next call.
+// When the terminal is in raw mode, newlines don't return the cursor to the start of the line, so rawMode adds a \r
+func RedrawFrame(frame string, previousLines int, rawMode bool) (string, int) {
+	redraw := ""
+	if previousLines > 0 {
+		redraw = fmt.Sprintf("\x1b[%dA\r\x1b[J", previousLines)
+	}
+	if rawMode {
+		frame = strings.ReplaceAll(frame, "\n", "\r\n")
+	}
+	return redraw + frame, strings.Count(frame, "\n")
+}
@@ -0,0 +1,144 @@
+package pokesay
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	// BorderStyles are the named border themes, that can be chosen with --border-style.
+	// More can be defined in the config file, see LoadBorderStyle
+	BorderStyles map[string]*BoxChars = map[string]*BoxChars{
+		"ascii":   AsciiBoxChars,
+		"unicode": UnicodeBoxChars,
+		"rounded": UnicodeBoxChars,
+		"double": {
+			HorizontalEdge: "═", VerticalEdge: "║",
+			TopRightCorner: "╗", TopLeftCorner: "╔", BottomRightCorner: "╝", BottomLeftCorner: "╚",
+			BalloonString: "╲", BalloonTether: "╦",
+			Separator: "║", RightArrow: "→", CategorySeparator: "/",
+		},
+		"heavy": {
+			HorizontalEdge: "━", VerticalEdge: "┃",
+			TopRightCorner: "┓", TopLeftCorner: "┏", BottomRightCorner: "┛", BottomLeftCorner: "┗",
+			BalloonString: "╲", BalloonTether: "┳",
+			Separator: "┃", RightArrow: "→", CategorySeparator: "/",
+		},
+		"dashed": {
+			HorizontalEdge: "┄", VerticalEdge: "┆",
+			TopRightCorner: "╮", TopLeftCorner: "╭", BottomRightCorner: "╯", BottomLeftCorner: "╰",
+			BalloonString: "╲", BalloonTether: "╲",
+			Separator: "┆", RightArrow: "→", CategorySeparator: "/",
+		},
+		"block": {
+			HorizontalEdge: "█", VerticalEdge: "█",
+			TopRightCorner: "█", TopLeftCorner: "█", BottomRightCorner: "█", BottomLeftCorner: "█",
+			BalloonString: "▚", BalloonTether: "▜",
+			Separator: "▐", RightArrow: "▶", CategorySeparator: "/",
+		},
+		"none": {
+			HorizontalEdge: " ", VerticalEdge: " ",
+			TopRightCorner: " ", TopLeftCorner: " ", BottomRightCorner: " ", BottomLeftCorner: " ",
+			BalloonString: "╲", BalloonTether: " ",
+			Separator: "│", RightArrow: "→", CategorySeparator: "/",
+		},
+	}
+	// the display width of each glyph is measured without east asian widths,
+	// so that the result doesn't depend on the locale of the terminal
+	glyphWidth *runewidth.Condition = &runewidth.Condition{EastAsianWidth: false}
+)
+
+// BorderStyleNames returns the sorted names of the built-in border styles
+func BorderStyleNames() []string {
+	names := make([]string, 0, len(BorderStyles))
+	for name := range BorderStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadBorderStyle returns the border style with a name. User-defined styles are read from config sections
+// named like [border-styles.<name>], with a key for each glyph, e.g.
+//
+//	[border-styles.stars]
+//	base = "ascii"          # the style to copy any missing glyphs from (default "rounded")
+//	horizontal-edge = "*"
+//	vertical-edge = "*"
+//
+// User-defined styles take priority over the built-in styles with the same name
+func LoadBorderStyle(name string, sections map[string]map[string]string) (*BoxChars, error) {
+	if fields, ok := sections["border-styles."+name]; ok {
+		baseName := fields["base"]
+		if baseName == "" {
+			baseName = "rounded"
+		}
+		base, ok := BorderStyles[baseName]
+		if !ok {
+			return nil, fmt.Errorf("unknown base '%s' for border style '%s'", baseName, name)
+		}
+		boxChars, err := NewBoxChars(fields, base)
+		if err != nil {
+			return nil, fmt.Errorf("invalid border style '%s': %w", name, err)
+		}
+		return boxChars, nil
+	}
+	if boxChars, ok := BorderStyles[name]; ok {
+		return boxChars, nil
+	}
+	return nil, fmt.Errorf("unknown border style '%s', expected one of: %s", name, strings.Join(BorderStyleNames(), ", "))
+}
+
+// NewBoxChars creates a BoxChars from a {glyph name -> glyph} map, using the glyphs from base for any that are missing.
+// The glyph names are the kebab-case BoxChars field names, e.g. "top-left-corner".
+// Every glyph must be a single display column wide, so that the borders line up
+func NewBoxChars(fields map[string]string, base *BoxChars) (*BoxChars, error) {
+	boxChars := *base
+	value := reflect.ValueOf(&boxChars).Elem()
+	known := map[string]bool{"base": true}
+
+	for i := 0; i < value.NumField(); i++ {
+		key := kebabCase(value.Type().Field(i).Name)
+		known[key] = true
+		if glyph, ok := fields[key]; ok {
+			value.Field(i).SetString(glyph)
+		}
+	}
+	for key := range fields {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown glyph '%s'", key)
+		}
+	}
+	return &boxChars, boxChars.Validate()
+}
+
+// Validate checks that every glyph is a single display column wide
+func (b *BoxChars) Validate() error {
+	value := reflect.ValueOf(b).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		glyph := value.Field(i).String()
+		if glyphWidth.StringWidth(glyph) != 1 {
+			return fmt.Errorf("%s '%s' must be a single column wide", kebabCase(value.Type().Field(i).Name), glyph)
+		}
+	}
+	return nil
+}
+
+// kebabCase converts a field name to a config key, e.g. TopLeftCorner -> top-left-corner
+func kebabCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if 'A' <= r && r <= 'Z' {
+			if i > 0 {
+				b.WriteRune('-')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
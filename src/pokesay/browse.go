@@ -0,0 +1,408 @@
+package pokesay
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BrowseAction is what the caller of Browser.HandleKey should do after a key press
+type BrowseAction int
+
+const (
+	BrowseNone        BrowseAction = iota // just redraw the browser
+	BrowseQuit                            // close the browser
+	BrowseCopyID                          // copy the ID of the selected sprite
+	BrowseCopyCommand                     // copy the command line that prints the selected sprite
+)
+
+// BrowseHelp is the list of keys shown at the bottom of the browser (cut to the width of the terminal)
+const BrowseHelp string = "q quit  / search  ↑↓ move  ←→ sprite  s shiny  f flip  tab/space categories  y copy ID  c copy command"
+
+// BrowseItem is a pokemon in the browser, with all of its sprites
+type BrowseItem struct {
+	Name    string
+	Sprites []Choice
+}
+
+// Browser is the state of the full-screen pokedex browser (`pokesay browse`).
+// It doesn't read from or write to the terminal, so the caller reads the keys (see ParseKeys),
+// passes them to HandleKey, and then draws the result of Render
+type Browser struct {
+	Items          []BrowseItem
+	Categories     []string        // the categories that can be toggled, e.g. small, gen8 & shiny
+	Enabled        map[string]bool // the toggled categories, which the sprites must all have
+	Query          string          // only pokemon whose names contain the query are listed
+	Searching      bool            // keys are typed into the query, instead of being commands
+	Flip           bool            // the preview is flipped horizontally
+	Filter         string          // the colour filter of the preview, one of Filters, or "" for none
+	Cursor         int             // the index of the selected pokemon, in Visible()
+	Variant        int             // the index of the selected sprite, in the matching sprites of the selected pokemon
+	CategoryCursor int             // the index of the category that space toggles
+	Status         string          // a message shown instead of the help, e.g. after copying
+	offset         int             // the index of the first pokemon shown in the list
+	pageSize       int             // the number of pokemon shown in the list
+}
+
+// NewBrowser creates a browser for choices, grouping the sprites of each pokemon in order.
+// The categories that can be toggled are those from the cowfile paths (e.g. gen8, but not type:fire)
+func NewBrowser(choices []Choice) *Browser {
+	b := &Browser{Enabled: make(map[string]bool)}
+	for i, choice := range choices {
+		if i == 0 || choice.Metadata.Idx != choices[i-1].Metadata.Idx || choice.Pack != choices[i-1].Pack {
+			b.Items = append(b.Items, BrowseItem{Name: choice.Metadata.Name})
+		}
+		item := &b.Items[len(b.Items)-1]
+		item.Sprites = append(item.Sprites, choice)
+		for _, category := range choice.Entry.Categories {
+			if !strings.Contains(category, ":") && !slices.Contains(b.Categories, category) {
+				b.Categories = append(b.Categories, category)
+			}
+		}
+	}
+	slices.SortStableFunc(b.Items, func(a, b BrowseItem) int { return strings.Compare(a.Name, b.Name) })
+	slices.Sort(b.Categories)
+	return b
+}
+
+// matching returns the sprites of a pokemon that have all of the toggled categories
+func (b *Browser) matching(item BrowseItem) []Choice {
+	sprites := make([]Choice, 0, len(item.Sprites))
+	for _, sprite := range item.Sprites {
+		matched := true
+		for category, enabled := range b.Enabled {
+			if enabled && !slices.Contains(sprite.Entry.Categories, category) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			sprites = append(sprites, sprite)
+		}
+	}
+	return sprites
+}
+
+// Visible returns the pokemon that match the query & toggled categories
+func (b *Browser) Visible() []BrowseItem {
+	query := strings.ToLower(b.Query)
+	visible := make([]BrowseItem, 0)
+	for _, item := range b.Items {
+		if strings.Contains(strings.ToLower(item.Name), query) && len(b.matching(item)) > 0 {
+			visible = append(visible, item)
+		}
+	}
+	return visible
+}
+
+// Selected returns the selected sprite, or false if no pokemon match the query & categories
+func (b *Browser) Selected() (Choice, bool) {
+	visible := b.Visible()
+	if len(visible) == 0 {
+		return Choice{}, false
+	}
+	sprites := b.matching(visible[b.Cursor])
+	return sprites[b.Variant], true
+}
+
+// CommandLine returns the pokesay command that prints the selected sprite, as it's shown in the preview
+func (b *Browser) CommandLine() string {
+	choice, ok := b.Selected()
+	if !ok {
+		return ""
+	}
+	command := "pokesay -i " + choice.ID
+	if b.Flip {
+		command += " -F"
+	}
+	if b.Filter != "" {
+		command += " --filter=" + b.Filter
+	}
+	return command
+}
+
+// clamp keeps the cursor & variant in range, e.g. after the query has changed
+func (b *Browser) clamp() {
+	visible := b.Visible()
+	b.Cursor = max(0, min(b.Cursor, len(visible)-1))
+	if len(visible) == 0 {
+		b.Variant = 0
+		return
+	}
+	b.Variant = max(0, min(b.Variant, len(b.matching(visible[b.Cursor]))-1))
+}
+
+// move moves the cursor by n pokemon, and selects the first sprite of the new pokemon
+func (b *Browser) move(n int) {
+	if cursor := max(0, min(b.Cursor+n, len(b.Visible())-1)); cursor != b.Cursor {
+		b.Cursor, b.Variant = cursor, 0
+	}
+}
+
+// toggleShiny selects the shiny version of a regular sprite, or the regular version of a shiny sprite
+func (b *Browser) toggleShiny() {
+	choice, ok := b.Selected()
+	if !ok {
+		return
+	}
+	swap := map[string]string{"shiny": "regular", "regular": "shiny"}
+	want := make([]string, 0, len(choice.Entry.Categories))
+	for _, category := range choice.Entry.Categories {
+		want = append(want, cmp.Or(swap[category], category))
+	}
+	for i, sprite := range b.matching(b.Visible()[b.Cursor]) {
+		if slices.Equal(sprite.Entry.Categories, want) {
+			b.Variant = i
+			return
+		}
+	}
+	b.Status = fmt.Sprintf("%s has no %s sprite that matches the categories", choice.Metadata.Name, strings.Join(want, "/"))
+}
+
+// HandleKey updates the browser after a key press (see ParseKeys for the key names)
+func (b *Browser) HandleKey(key string) BrowseAction {
+	b.Status = ""
+	if key == "ctrl-c" {
+		return BrowseQuit
+	}
+	if b.Searching {
+		switch key {
+		case "enter", "up", "down":
+			b.Searching = false
+		case "esc":
+			b.Searching, b.Query = false, ""
+		case "backspace":
+			if _, size := utf8.DecodeLastRuneInString(b.Query); size > 0 {
+				b.Query = b.Query[:len(b.Query)-size]
+			}
+		case "ctrl-u":
+			b.Query = ""
+		default:
+			if utf8.RuneCountInString(key) == 1 {
+				b.Query += key
+			}
+		}
+		b.clamp()
+		return BrowseNone
+	}
+
+	pageSize := cmp.Or(b.pageSize, 10)
+	switch key {
+	case "q", "esc":
+		return BrowseQuit
+	case "/":
+		b.Searching = true
+	case "up", "k":
+		b.move(-1)
+	case "down", "j":
+		b.move(1)
+	case "pgup":
+		b.move(-pageSize)
+	case "pgdown":
+		b.move(pageSize)
+	case "home", "g":
+		b.move(-b.Cursor)
+	case "end", "G":
+		b.move(len(b.Visible()))
+	case "left", "h", "right", "l":
+		if visible := b.Visible(); len(visible) > 0 {
+			n := len(b.matching(visible[b.Cursor]))
+			step := 1
+			if key == "left" || key == "h" {
+				step = n - 1
+			}
+			b.Variant = (b.Variant + step) % n
+		}
+	case "s":
+		b.toggleShiny()
+	case "f":
+		b.Flip = !b.Flip
+	case "tab":
+		b.CategoryCursor = (b.CategoryCursor + 1) % max(1, len(b.Categories))
+	case "shift-tab":
+		b.CategoryCursor = (b.CategoryCursor + len(b.Categories) - 1) % max(1, len(b.Categories))
+	case " ":
+		if len(b.Categories) > 0 {
+			category := b.Categories[b.CategoryCursor]
+			b.Enabled[category] = !b.Enabled[category]
+			b.clamp()
+		}
+	case "y":
+		return BrowseCopyID
+	case "c":
+		return BrowseCopyCommand
+	}
+	return BrowseNone
+}
+
+// keyNames are the names of the escape sequences & control characters sent by keys
+var keyNames map[string]string = map[string]string{
+	"\x1b[A": "up", "\x1b[B": "down", "\x1b[C": "right", "\x1b[D": "left",
+	"\x1bOA": "up", "\x1bOB": "down", "\x1bOC": "right", "\x1bOD": "left",
+	"\x1b[H": "home", "\x1b[F": "end", "\x1b[1~": "home", "\x1b[4~": "end",
+	"\x1b[5~": "pgup", "\x1b[6~": "pgdown", "\x1b[Z": "shift-tab",
+	"\x1b": "esc", "\r": "enter", "\n": "enter", "\t": "tab", "\x7f": "backspace", "\b": "backspace",
+	"\x03": "ctrl-c", "\x15": "ctrl-u",
+}
+
+// ParseKeys converts the bytes read from a terminal in raw mode into key names, e.g. "up", "enter" or "a".
+// Printable characters are their own names, and any other escape sequences & control characters are skipped
+func ParseKeys(data []byte) []string {
+	keys := make([]string, 0)
+	for s := string(data); s != ""; {
+		var key string
+		switch {
+		case strings.HasPrefix(s, "\x1bO") && len(s) >= 3:
+			// the arrow keys in application mode, e.g. \x1bOA
+			key = s[:3]
+		case s[0] == '\x1b':
+			key = escapeSequenceRegex.FindString(s)
+		default:
+			_, size := utf8.DecodeRuneInString(s)
+			key = s[:size]
+		}
+		s = s[len(key):]
+
+		if name, ok := keyNames[key]; ok {
+			keys = append(keys, name)
+		} else if r, _ := utf8.DecodeRuneInString(key); r != '\x1b' && r != utf8.RuneError && !unicode.IsControl(r) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// ClipboardSequence returns the OSC 52 escape sequence that copies text to the clipboard
+// (this works over ssh, but some terminals need it to be enabled)
+func ClipboardSequence(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+// clipANSILine cuts a line of tokens to a width
+func clipANSILine(tokens []ANSILineToken, width int) []ANSILineToken {
+	clipped := make([]ANSILineToken, 0, len(tokens))
+	for _, token := range tokens {
+		if width <= 0 {
+			break
+		}
+		runes := []rune(token.T)
+		if len(runes) > width {
+			runes = runes[:width]
+		}
+		width -= len(runes)
+		clipped = append(clipped, ANSILineToken{FG: token.FG, BG: token.BG, T: string(runes)})
+	}
+	return clipped
+}
+
+// padRight pads or cuts text to a display width
+func padRight(text string, width int) string {
+	runes := []rune(text)
+	for UnicodeStringLength(string(runes)) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + strings.Repeat(" ", width-UnicodeStringLength(string(runes)))
+}
+
+// preview returns the lines of the selected sprite, flipped & filtered, and shrunk to fit in a width & height
+func (b *Browser) preview(choice Choice, sprite func(Choice) []byte, width int, height int) [][]ANSILineToken {
+	lines := TokeniseANSIString(string(sprite(choice)))
+	if n := len(lines); n > 0 && len(lines[n-1]) == 0 {
+		lines = lines[:n-1]
+	}
+	spriteWidth := 0
+	for _, tokens := range lines {
+		lineWidth := 0
+		for _, token := range tokens {
+			lineWidth += UnicodeStringLength(token.T)
+		}
+		spriteWidth = max(spriteWidth, lineWidth)
+	}
+	scale := ScaleForHeight(lines, 1, height)
+	if spriteWidth > width {
+		scale = min(scale, float64(width)/float64(spriteWidth))
+	}
+	if scale < 1 {
+		lines = ScaleANSIString(lines, scale)
+	}
+	lines = FilterANSIString(lines, b.Filter)
+	if b.Flip {
+		lines = FlipANSIString(lines, FlipHorizontal)
+	}
+	return lines
+}
+
+// Render draws the whole browser for a terminal size, using sprite to read the sprite of the selected pokemon
+// - the top lines are the search query, and the categories (toggled categories are marked with [x])
+// - the left pane lists the matching pokemon, and the right pane shows the selected sprite & its information
+// - the bottom line is the help, or a status message
+func (b *Browser) Render(width int, height int, sprite func(Choice) []byte) string {
+	var screen strings.Builder
+	screen.WriteString("\x1b[H\x1b[2J")
+	line := func(row int, col int, text string) {
+		fmt.Fprintf(&screen, "\x1b[%d;%dH%s\x1b[0m", row, col, text)
+	}
+	reverse := func(text string) string { return "\x1b[7m" + text + "\x1b[27m" }
+
+	visible := b.Visible()
+	search := "/" + b.Query
+	if b.Searching {
+		search += reverse(" ")
+	}
+	line(1, 1, fmt.Sprintf("%s  (%d of %d pokemon)", search, len(visible), len(b.Items)))
+
+	categories := make([]string, 0, len(b.Categories))
+	for i, category := range b.Categories {
+		mark := "[ ]"
+		if b.Enabled[category] {
+			mark = "[x]"
+		}
+		if i == b.CategoryCursor {
+			categories = append(categories, reverse(mark+" "+category))
+		} else {
+			categories = append(categories, mark+" "+category)
+		}
+	}
+	line(2, 1, strings.Join(categories, " "))
+
+	listWidth := max(10, min(30, width/3))
+	listHeight := max(1, height-4)
+	b.offset = max(0, min(b.offset, b.Cursor), b.Cursor-listHeight+1)
+	b.pageSize = listHeight
+	for row := 0; row < listHeight && b.offset+row < len(visible); row++ {
+		idx := b.offset + row
+		name := padRight(" "+visible[idx].Name, listWidth)
+		if idx == b.Cursor {
+			name = reverse(name)
+		}
+		line(row+4, 1, name)
+	}
+
+	if choice, ok := b.Selected(); ok {
+		col, previewWidth := listWidth+3, max(1, width-listWidth-3)
+		info := []string{
+			textStyleBold.Sprint(choice.Metadata.Name),
+			choice.ID,
+			strings.Join(choice.Entry.Categories, ", "),
+			fmt.Sprintf("sprite %d of %d", b.Variant+1, len(b.matching(visible[b.Cursor]))),
+		}
+		lines := b.preview(choice, sprite, previewWidth, max(1, listHeight-len(info)-1))
+		for row, tokens := range lines {
+			line(row+4, col, strings.TrimSuffix(BuildANSIString([][]ANSILineToken{clipANSILine(tokens, previewWidth)}, 0), "\n"))
+		}
+		for row, text := range info {
+			line(len(lines)+row+5, col, text)
+		}
+	}
+
+	if b.Status != "" {
+		line(height, 1, b.Status)
+	} else {
+		line(height, 1, padRight(BrowseHelp, width))
+	}
+	return screen.String()
+}
@@ -0,0 +1,182 @@
+package pokesay
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AutoColour is the colour option value that picks the colour from the dominant colour of the chosen pokemon
+const AutoColour = "auto"
+
+var (
+	namedColours map[string]int = map[string]int{
+		"black": 30, "red": 31, "green": 32, "yellow": 33, "blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+	}
+	hexColourRegex   *regexp.Regexp = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	xtermColourRegex *regexp.Regexp = regexp.MustCompile(`\x1b\[[34]8;5;(\d+)m`)
+	rgbColourRegex   *regexp.Regexp = regexp.MustCompile(`\x1b\[[34]8;2;(\d+);(\d+);(\d+)m`)
+)
+
+// ParseColour converts a colour option into the ANSI escape code that sets the text colour. Colours can be
+// - a hex RGB colour, e.g. #ff8800 or #f80
+// - an xterm 256 colour number, e.g. 208
+// - a basic colour name, e.g. red (black, red, green, yellow, blue, magenta, cyan, white)
+//
+// An empty colour returns an empty escape code, and AutoColour is returned unchanged
+func ParseColour(colour string) (string, error) {
+	colour = strings.ToLower(strings.TrimSpace(colour))
+	if colour == "" || colour == AutoColour {
+		return colour, nil
+	}
+	if code, ok := namedColours[colour]; ok {
+		return fmt.Sprintf("\x1b[%dm", code), nil
+	}
+	if n, err := strconv.Atoi(colour); err == nil && n >= 0 && n <= 255 {
+		return fmt.Sprintf("\x1b[38;5;%dm", n), nil
+	}
+	if hexColourRegex.MatchString(colour) {
+		hex := colour[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, _ := strconv.ParseUint(hex, 16, 32)
+		return fmt.Sprintf("\x1b[38;2;%d;%d;%dm", rgb>>16, (rgb>>8)&0xff, rgb&0xff), nil
+	}
+	return "", fmt.Errorf("invalid colour '%s', expected #rrggbb, a number from 0-255, a colour name or %s", colour, AutoColour)
+}
+
+// ColourNames returns the names of the basic colours, in the order of their ANSI codes
+func ColourNames() []string {
+	names := make([]string, 0, len(namedColours))
+	for name := range namedColours {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int { return namedColours[a] - namedColours[b] })
+	return names
+}
+
+// DominantColour finds the colour that covers the most of a pokemon sprite, from the FG & BG colours of its tokens,
+// and returns the ANSI escape code that sets the text to that colour.
+// Very dark colours (e.g. the black outlines of the sprites) are only used if there is no other colour.
+// If the sprite has no colours at all, an empty string is returned
+func DominantColour(lines [][]ANSILineToken) string {
+	counts := make(map[string]int)
+	order := make([]string, 0)
+	count := func(code string, n int) {
+		colour := colourOf(code)
+		if colour == "" || n == 0 {
+			return
+		}
+		if _, ok := counts[colour]; !ok {
+			order = append(order, colour)
+		}
+		counts[colour] += n
+	}
+	for _, tokens := range lines {
+		for _, token := range tokens {
+			// every cell shows the BG colour (at least in part), and every non-space cell shows the FG colour
+			count(token.BG, UnicodeStringLength(token.T))
+			count(token.FG, UnicodeStringLength(strings.ReplaceAll(token.T, " ", "")))
+		}
+	}
+
+	dominant, dominantDark := "", ""
+	for _, colour := range order {
+		if isDark(colour) {
+			if dominantDark == "" || counts[colour] > counts[dominantDark] {
+				dominantDark = colour
+			}
+		} else if dominant == "" || counts[colour] > counts[dominant] {
+			dominant = colour
+		}
+	}
+	if dominant == "" {
+		dominant = dominantDark
+	}
+	return dominant
+}
+
+// colourOf converts a FG or BG colour escape code into the equivalent FG colour escape code,
+// e.g. "\x1b[48;5;208m" -> "\x1b[38;5;208m". Resets & default colours return an empty string
+func colourOf(code string) string {
+	if m := xtermColourRegex.FindStringSubmatch(code); m != nil {
+		return fmt.Sprintf("\x1b[38;5;%sm", m[1])
+	}
+	if m := rgbColourRegex.FindStringSubmatch(code); m != nil {
+		return fmt.Sprintf("\x1b[38;2;%s;%s;%sm", m[1], m[2], m[3])
+	}
+	return ""
+}
+
+// isDark returns true if a FG colour escape code is close to black
+func isDark(colour string) bool {
+	r, g, b := colourRGB(colour)
+	return max(r, g, b) < 64
+}
+
+// colourRGB returns the RGB values of a FG colour escape code from colourOf
+func colourRGB(colour string) (float64, float64, float64) {
+	r, g, b := 0, 0, 0
+	if m := xtermColourRegex.FindStringSubmatch(colour); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		r, g, b = xtermRGB(n)
+	} else if m := rgbColourRegex.FindStringSubmatch(colour); m != nil {
+		r, _ = strconv.Atoi(m[1])
+		g, _ = strconv.Atoi(m[2])
+		b, _ = strconv.Atoi(m[3])
+	}
+	return float64(r), float64(g), float64(b)
+}
+
+// nearestXterm returns the xterm 256 colour that is closest to an RGB colour.
+// The system colours (0-15) are skipped, as they depend on the terminal theme
+func nearestXterm(r, g, b float64) int {
+	nearest, distance := 16, math.Inf(1)
+	for n := 16; n < 256; n++ {
+		xr, xg, xb := xtermRGB(n)
+		dr, dg, db := float64(xr)-r, float64(xg)-g, float64(xb)-b
+		if d := dr*dr + dg*dg + db*db; d < distance {
+			nearest, distance = n, d
+		}
+	}
+	return nearest
+}
+
+// xtermRGB returns the RGB values of an xterm 256 colour number
+func xtermRGB(n int) (int, int, int) {
+	switch {
+	case n < 16:
+		// the system colours, which depend on the terminal theme, so these are approximations
+		if n == 7 {
+			return 192, 192, 192
+		} else if n == 8 {
+			return 128, 128, 128
+		}
+		level := 128
+		if n > 8 {
+			level = 255
+		}
+		return (n & 1) * level, (n >> 1 & 1) * level, (n >> 2 & 1) * level
+	case n < 232:
+		// the 6x6x6 colour cube
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n -= 16
+		return levels[n/36], levels[n/6%6], levels[n%6]
+	default:
+		// the grayscale ramp
+		level := 8 + (n-232)*10
+		return level, level, level
+	}
+}
+
+// paint wraps text in a colour escape code, and resets the colour afterwards
+func paint(colour string, text string) string {
+	if colour == "" {
+		return text
+	}
+	return colour + text + resetColourANSI
+}
@@ -0,0 +1,334 @@
+package pokesay
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// CompletionShells are the shells that completion scripts can be generated for
+var CompletionShells []string = []string{"bash", "zsh", "fish"}
+
+// Completion describes the values that can be completed for a flag or command parameter.
+// Only one of the fields is expected to be set, and an empty Completion completes nothing
+type Completion struct {
+	Words   []string // a fixed list of values, e.g. the output formats
+	Command string   // the pokesay command that prints the values one per line, e.g. "list names"
+	Files   bool     // complete file paths
+	Dirs    bool     // complete directory paths
+}
+
+// FlagInfo describes a command line flag, for the completion scripts & man page
+type FlagInfo struct {
+	Short      string // the short name without the "-", or "" if the flag has none
+	Long       string // the long name without the "--", or "" if the flag has none
+	Help       string
+	Value      string // the name of the value the flag takes, or "" for flags that don't take one
+	Optional   bool   // true if the value is optional, in which case it must be joined to the flag, e.g. -lpika or --list-names=pika
+	Default    string // the default value, or "" if there isn't one
+	Repeatable bool   // true if the flag can be given more than once
+	Complete   Completion
+}
+
+// CommandInfo describes a subcommand, for the completion scripts & man page
+type CommandInfo struct {
+	Name     string
+	Usage    string // the parameters of the command, e.g. "<name|id>"
+	Help     string
+	Complete Completion // the values of the first parameter of the command
+}
+
+// MatchUsage returns true if the parameters of a command match its usage, so that they can be told apart from a message
+// that starts with the name of a command, e.g. `pokesay show me the money`.
+// Each word of the usage matches one parameter: <value> matches anything, a|b matches one of the alternatives,
+// and [value ...] matches any remaining parameters
+func MatchUsage(usage string, params []string) bool {
+	words := strings.Fields(usage)
+	for i, word := range words {
+		if strings.HasPrefix(word, "[") {
+			return true
+		}
+		if i >= len(params) {
+			return false
+		}
+		if !strings.HasPrefix(word, "<") && !slices.Contains(strings.Split(word, "|"), params[i]) {
+			return false
+		}
+	}
+	return len(params) == len(words)
+}
+
+// WriteCompletion writes the completion script for a shell. The scripts call the program to complete
+// the names, IDs & categories, so that they always match the pokemon that the program embeds
+func WriteCompletion(w io.Writer, shell string, program string, flags []FlagInfo, commands []CommandInfo) error {
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, program, flags, commands)
+	case "zsh":
+		writeZshCompletion(w, program, flags, commands)
+	case "fish":
+		writeFishCompletion(w, program, flags, commands)
+	default:
+		return fmt.Errorf("invalid shell '%s', expected one of: %s", shell, strings.Join(CompletionShells, ", "))
+	}
+	return nil
+}
+
+// completesValue returns true if the completion scripts should complete a value after the flag.
+// Optional values are never completed, as they must be joined to the flag
+func (flag FlagInfo) completesValue() bool {
+	return flag.Value != "" && !flag.Optional
+}
+
+// flagNames returns the names of a flag with their dashes, e.g. ["-n", "--name"]
+func (flag FlagInfo) flagNames() []string {
+	names := make([]string, 0, 2)
+	if flag.Short != "" {
+		names = append(names, "-"+flag.Short)
+	}
+	if flag.Long != "" {
+		names = append(names, "--"+flag.Long)
+	}
+	return names
+}
+
+func commandNames(commands []CommandInfo) []string {
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		names = append(names, command.Name)
+	}
+	return names
+}
+
+// bashCompgen returns the bash expression that completes the values of a Completion
+func bashCompgen(program string, complete Completion) string {
+	switch {
+	case len(complete.Words) > 0:
+		return fmt.Sprintf(`compgen -W "%s" -- "${cur}"`, strings.Join(complete.Words, " "))
+	case complete.Command != "":
+		return fmt.Sprintf(`compgen -W "$(%s %s 2>/dev/null)" -- "${cur}"`, program, complete.Command)
+	case complete.Files:
+		return `compgen -f -- "${cur}"`
+	case complete.Dirs:
+		return `compgen -d -- "${cur}"`
+	}
+	return ""
+}
+
+func writeBashCompletion(w io.Writer, program string, flags []FlagInfo, commands []CommandInfo) {
+	fmt.Fprintf(w, "# %s bash completion, generated by `%s completion bash`\n\n", program, program)
+	fmt.Fprintf(w, "_%s() {\n", program)
+	fmt.Fprintln(w, `    local cur prev cmd i`)
+	fmt.Fprintln(w, `    COMPREPLY=()`)
+	fmt.Fprintln(w, `    cur="${COMP_WORDS[COMP_CWORD]}"`)
+	fmt.Fprintln(w, `    prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w)
+
+	// the values of the flags that take one
+	fmt.Fprintln(w, `    case "${prev}" in`)
+	for _, flag := range flags {
+		if !flag.completesValue() {
+			continue
+		}
+		fmt.Fprintf(w, "        %s)\n", strings.Join(flag.flagNames(), "|"))
+		if compgen := bashCompgen(program, flag.Complete); compgen != "" {
+			fmt.Fprintf(w, "            COMPREPLY=( $(%s) )\n", compgen)
+		}
+		fmt.Fprintln(w, `            return 0 ;;`)
+	}
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w)
+
+	allFlags := make([]string, 0)
+	for _, flag := range flags {
+		allFlags = append(allFlags, flag.flagNames()...)
+	}
+	fmt.Fprintln(w, `    if [[ "${cur}" == -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=( $(compgen -W \"%s\" -- \"${cur}\") )\n", strings.Join(allFlags, " "))
+	fmt.Fprintln(w, `        return 0`)
+	fmt.Fprintln(w, `    fi`)
+	fmt.Fprintln(w)
+
+	// the parameters of the command, if one has been given already
+	names := strings.Join(commandNames(commands), "|")
+	fmt.Fprintln(w, `    cmd=""`)
+	fmt.Fprintln(w, `    for ((i = 1; i < COMP_CWORD; i++)); do`)
+	fmt.Fprintln(w, `        case "${COMP_WORDS[i]}" in`)
+	fmt.Fprintf(w, "            %s) cmd=\"${COMP_WORDS[i]}\"; break ;;\n", names)
+	fmt.Fprintln(w, `        esac`)
+	fmt.Fprintln(w, `    done`)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `    case "${cmd}" in`)
+	fmt.Fprintf(w, "        \"\") COMPREPLY=( $(compgen -W \"%s\" -- \"${cur}\") ) ;;\n", strings.Join(commandNames(commands), " "))
+	for _, command := range commands {
+		if compgen := bashCompgen(program, command.Complete); compgen != "" {
+			fmt.Fprintf(w, "        %s) [[ \"${prev}\" == %s ]] && COMPREPLY=( $(%s) ) ;;\n", command.Name, command.Name, compgen)
+		}
+	}
+	fmt.Fprintln(w, `    esac`)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "complete -F _%s %s\n", program, program)
+}
+
+// zshQuote escapes the text of a zsh _arguments spec, which is wrapped in single quotes
+func zshQuote(text string) string {
+	return strings.NewReplacer(`'`, `'\''`, `[`, `\[`, `]`, `\]`).Replace(text)
+}
+
+// zshAction returns the zsh _arguments action that completes the values of a Completion
+func zshAction(program string, complete Completion) string {
+	switch {
+	case len(complete.Words) > 0:
+		return "(" + strings.Join(complete.Words, " ") + ")"
+	case complete.Command != "":
+		return fmt.Sprintf("_%s_values %s", program, complete.Command)
+	case complete.Files:
+		return "_files"
+	case complete.Dirs:
+		return "_files -/"
+	}
+	return " "
+}
+
+func writeZshCompletion(w io.Writer, program string, flags []FlagInfo, commands []CommandInfo) {
+	fmt.Fprintf(w, "#compdef %s\n", program)
+	fmt.Fprintf(w, "# %s zsh completion, generated by `%s completion zsh`\n\n", program, program)
+
+	fmt.Fprintf(w, "_%s_values() {\n", program)
+	fmt.Fprintln(w, `  local -a values`)
+	fmt.Fprintf(w, "  values=(${(f)\"$(%s \"$@\" 2>/dev/null)\"})\n", program)
+	fmt.Fprintln(w, `  compadd -a values`)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w)
+
+	fmt.Fprintf(w, "_%s() {\n", program)
+	fmt.Fprintln(w, `  local -a commands`)
+	fmt.Fprintln(w, `  local context state state_descr line`)
+	fmt.Fprintln(w, `  commands=(`)
+	for _, command := range commands {
+		fmt.Fprintf(w, "    '%s:%s'\n", command.Name, zshQuote(command.Help))
+	}
+	fmt.Fprintln(w, `  )`)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, `  _arguments -s -C \`)
+	for _, flag := range flags {
+		names := flag.flagNames()
+		spec := ""
+		if flag.Repeatable {
+			spec = "'*'"
+		} else if len(names) > 1 {
+			spec = "'(" + strings.Join(names, " ") + ")'"
+		}
+		if flag.completesValue() {
+			// -n takes the value as the next word or joined (-npikachu), --name as the next word or after an =
+			for i, name := range names {
+				if strings.HasPrefix(name, "--") {
+					names[i] = name + "="
+				} else {
+					names[i] = name + "+"
+				}
+			}
+		}
+		if len(names) > 1 {
+			spec += "{" + strings.Join(names, ",") + "}"
+		} else {
+			spec += names[0]
+		}
+		spec += "'[" + zshQuote(flag.Help) + "]"
+		if flag.completesValue() {
+			spec += ":" + zshQuote(flag.Value) + ":" + zshAction(program, flag.Complete)
+		}
+		fmt.Fprintf(w, "    %s' \\\n", spec)
+	}
+	fmt.Fprintln(w, `    '1: :->command' \`)
+	fmt.Fprintln(w, `    '*:: :->params'`)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, `  case "${state}" in`)
+	fmt.Fprintln(w, `    command) _describe 'command' commands ;;`)
+	fmt.Fprintln(w, `    params)`)
+	fmt.Fprintln(w, `      (( CURRENT == 2 )) || return`)
+	fmt.Fprintln(w, `      case "${words[1]}" in`)
+	for _, command := range commands {
+		if action := zshAction(program, command.Complete); action != " " {
+			if len(command.Complete.Words) > 0 {
+				action = "compadd " + strings.Join(command.Complete.Words, " ")
+			}
+			fmt.Fprintf(w, "        %s) %s ;;\n", command.Name, action)
+		}
+	}
+	fmt.Fprintln(w, `      esac ;;`)
+	fmt.Fprintln(w, `  esac`)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w)
+
+	// support both autoloading from $fpath, and sourcing the script
+	fmt.Fprintf(w, "if [ \"${funcstack[1]}\" = \"_%s\" ]; then\n", program)
+	fmt.Fprintf(w, "  _%s \"$@\"\n", program)
+	fmt.Fprintln(w, `else`)
+	fmt.Fprintf(w, "  compdef _%s %s\n", program, program)
+	fmt.Fprintln(w, `fi`)
+}
+
+// fishQuote wraps text in single quotes for fish
+func fishQuote(text string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(text) + "'"
+}
+
+// fishArgs returns the fish complete arguments that complete the values of a Completion
+func fishArgs(program string, complete Completion) string {
+	switch {
+	case len(complete.Words) > 0:
+		return "-a " + fishQuote(strings.Join(complete.Words, " "))
+	case complete.Command != "":
+		return fmt.Sprintf("-a '(%s %s 2>/dev/null)'", program, complete.Command)
+	case complete.Files:
+		return "-F"
+	case complete.Dirs:
+		return "-a '(__fish_complete_directories)'"
+	}
+	return ""
+}
+
+func writeFishCompletion(w io.Writer, program string, flags []FlagInfo, commands []CommandInfo) {
+	fmt.Fprintf(w, "# %s fish completion, generated by `%s completion fish`\n\n", program, program)
+	fmt.Fprintf(w, "complete -c %s -f\n\n", program)
+
+	for _, flag := range flags {
+		parts := []string{"complete", "-c", program}
+		if flag.Short != "" {
+			parts = append(parts, "-s", flag.Short)
+		}
+		if flag.Long != "" {
+			parts = append(parts, "-l", flag.Long)
+		}
+		parts = append(parts, "-d", fishQuote(flag.Help))
+		if flag.completesValue() {
+			parts = append(parts, "-r")
+			if args := fishArgs(program, flag.Complete); args != "" {
+				parts = append(parts, args)
+			}
+		}
+		fmt.Fprintln(w, strings.Join(parts, " "))
+	}
+	fmt.Fprintln(w)
+
+	for _, command := range commands {
+		fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a %s -d %s\n", program, command.Name, fishQuote(command.Help))
+	}
+	for _, command := range commands {
+		args := fishArgs(program, command.Complete)
+		if args == "" {
+			continue
+		}
+		condition := "__fish_seen_subcommand_from " + command.Name
+		if len(command.Complete.Words) > 0 {
+			// stop completing once one of the values has been given
+			condition += "; and not __fish_seen_subcommand_from " + strings.Join(command.Complete.Words, " ")
+		}
+		fmt.Fprintf(w, "complete -c %s -n '%s' %s\n", program, condition, args)
+	}
+}
@@ -0,0 +1,177 @@
+package pokesay
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds the options read from a config file, which is a small subset of TOML:
+//
+//	# defaults for any command line option, using the long option name
+//	width = 60
+//	unicode-borders = true
+//	category = "gen8"
+//	pack = ["/opt/pokesay/mascots", "/opt/pokesay/other"]
+//
+//	[section]
+//	key = "value"
+//
+// Values can be strings, booleans, integers, or arrays of these.
+// All values are stored as strings, in the same form they would be given on the command line
+// (arrays are joined with commas)
+type Config struct {
+	Options  map[string]string            // the top-level keys, which set the defaults of command line options
+	Sections map[string]map[string]string // the keys of each [section], e.g. for user-defined themes
+}
+
+// ConfigFpath returns the default path of the config file, $XDG_CONFIG_HOME/pokesay/config.toml,
+// falling back to ~/.config/pokesay/config.toml when $XDG_CONFIG_HOME isn't set
+func ConfigFpath() string {
+	configDir := os.Getenv("XDG_CONFIG_HOME")
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		configDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configDir, "pokesay", "config.toml")
+}
+
+// EnvKey returns the name of the environment variable for a command line option, e.g. "japanese-name" -> POKESAY_JAPANESE_NAME
+func EnvKey(option string) string {
+	return "POKESAY_" + strings.ToUpper(strings.ReplaceAll(option, "-", "_"))
+}
+
+// ReadConfig reads a config file. If the file doesn't exist and missingOK is set, an empty config is returned
+func ReadConfig(fpath string, missingOK bool) (Config, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		if missingOK && errors.Is(err, fs.ErrNotExist) {
+			return Config{Options: map[string]string{}, Sections: map[string]map[string]string{}}, nil
+		}
+		return Config{}, fmt.Errorf("could not read config file: %w", err)
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s:%w", fpath, err)
+	}
+	return config, nil
+}
+
+// ParseConfig parses the contents of a config file
+func ParseConfig(data []byte) (Config, error) {
+	config := Config{Options: map[string]string{}, Sections: map[string]map[string]string{}}
+	keys := config.Options
+
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return Config{}, fmt.Errorf("%d: invalid section '%s'", i+1, line)
+			}
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			if _, ok := config.Sections[section]; !ok {
+				config.Sections[section] = map[string]string{}
+			}
+			keys = config.Sections[section]
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("%d: expected 'key = value', got '%s'", i+1, line)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"'`)
+		parsed, err := parseConfigValue(strings.TrimSpace(value))
+		if err != nil {
+			return Config{}, fmt.Errorf("%d: %w for '%s'", i+1, err, key)
+		}
+		keys[key] = parsed
+	}
+	return config, nil
+}
+
+// stripComment removes a trailing # comment from a line, ignoring any # inside of quotes
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseConfigValue parses a string, boolean, integer, float or array value
+func parseConfigValue(value string) (string, error) {
+	switch {
+	case value == "":
+		return "", errors.New("missing value")
+	case strings.HasPrefix(value, `"`):
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return s, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("invalid string %s", value)
+		}
+		return value[1 : len(value)-1], nil
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return "", fmt.Errorf("invalid array %s", value)
+		}
+		items := make([]string, 0)
+		for _, item := range splitConfigArray(value[1 : len(value)-1]) {
+			parsed, err := parseConfigValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, parsed)
+		}
+		return strings.Join(items, ","), nil
+	case value == "true" || value == "false":
+		return value, nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return "", fmt.Errorf("invalid value %s (strings must be quoted)", value)
+	}
+	return value, nil
+}
+
+// splitConfigArray splits the items of an array on commas, ignoring any commas inside of quotes
+func splitConfigArray(value string) []string {
+	items := make([]string, 0)
+	var quote rune
+	start := 0
+	for i, r := range value {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+		case quote == 0 && r == ',':
+			items = append(items, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	// allow a trailing comma
+	if last := strings.TrimSpace(value[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items
+}
@@ -0,0 +1,123 @@
+package pokesay
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// matches the start of the heredoc that defines a cow, e.g. `$the_cow = <<"EOC";`,
+	// capturing the quote (if any) and the terminator
+	cowHeredocRegex *regexp.Regexp = regexp.MustCompile(`\$the_cow\s*=\s*<<\s*(["']?)(\w+)["']?\s*;?[^\n]*\n`)
+	// matches a perl variable at the start of a string, e.g. $thoughts or ${eyes}
+	cowVariableRegex *regexp.Regexp = regexp.MustCompile(`^\$(?:\{(\w+)\}|(\w+))`)
+	// matches a perl escape sequence in a double-quoted string, e.g. \e, \033, \x1b, \x{1b} or \N{U+2580}
+	perlEscapeRegex *regexp.Regexp = regexp.MustCompile(`\\(?:x\{([0-9a-fA-F]+)\}|x([0-9a-fA-F]{1,2})|N\{U\+([0-9a-fA-F]+)\}|([0-7]{1,3})|(.))`)
+	// the characters that are special in a perl double-quoted heredoc, and must be escaped when exporting
+	perlEscaper *strings.Replacer = strings.NewReplacer(`\`, `\\`, `$`, `\$`, `@`, `\@`, "\x1b", `\e`)
+)
+
+// cowTetherLines is the number of lines of the tether between the speech bubble and the pokemon
+const cowTetherLines = 4
+
+// ExportCowfile converts a pokemon sprite into a cowsay cowfile, for use with `cowsay -f`.
+// The tether to the speech bubble is drawn with $thoughts, in the same place that pokesay draws it
+func ExportCowfile(sprite []byte, comment string) []byte {
+	var cow strings.Builder
+	fmt.Fprintf(&cow, "# %s\n", comment)
+	cow.WriteString("$the_cow = <<\"EOC\";\n")
+	for i := 0; i < cowTetherLines; i++ {
+		cow.WriteString(strings.Repeat(" ", i+8) + "$thoughts\n")
+	}
+	cow.WriteString(perlEscaper.Replace(string(sprite)))
+	if len(sprite) > 0 && sprite[len(sprite)-1] != '\n' {
+		cow.WriteString("\n")
+	}
+	cow.WriteString("EOC\n")
+	return []byte(cow.String())
+}
+
+// ParseCowfile reads the sprite from a cowsay cowfile, from the $the_cow heredoc.
+// Perl escapes are replaced (unless the heredoc is single-quoted), and so are the variables that cowsay sets:
+// $thoughts is replaced with the tether character, $eyes with "oo" and $tongue with spaces
+func ParseCowfile(data []byte, thoughts string) ([]byte, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	match := cowHeredocRegex.FindStringSubmatchIndex(text)
+	if match == nil {
+		return nil, fmt.Errorf("invalid cowfile, expected a $the_cow = <<EOC heredoc")
+	}
+	quote, terminator := text[match[2]:match[3]], text[match[4]:match[5]]
+
+	lines := make([]string, 0)
+	terminated := false
+	for _, line := range strings.Split(text[match[1]:], "\n") {
+		if strings.TrimRight(line, " \t") == terminator {
+			terminated = true
+			break
+		}
+		lines = append(lines, line)
+	}
+	if !terminated {
+		return nil, fmt.Errorf("invalid cowfile, the $the_cow heredoc isn't terminated by %s", terminator)
+	}
+
+	cow := strings.Join(lines, "\n") + "\n"
+	if quote != "'" {
+		cow = replaceCowVariables(cow, thoughts)
+		cow = unescapePerl(cow)
+	}
+	return []byte(cow), nil
+}
+
+// replaceCowVariables replaces the variables that cowsay sets, in a cow that hasn't been unescaped yet.
+// Escaped dollar signs (\$) are left as they are
+func replaceCowVariables(cow string, thoughts string) string {
+	values := map[string]string{"thoughts": thoughts, "eyes": "oo", "tongue": "  "}
+	var replaced strings.Builder
+	for i := 0; i < len(cow); {
+		if cow[i] == '\\' && i+1 < len(cow) {
+			replaced.WriteString(cow[i : i+2])
+			i += 2
+			continue
+		}
+		if cow[i] == '$' {
+			if m := cowVariableRegex.FindStringSubmatch(cow[i:]); m != nil {
+				if value, ok := values[m[1]+m[2]]; ok {
+					replaced.WriteString(value)
+					i += len(m[0])
+					continue
+				}
+			}
+		}
+		replaced.WriteByte(cow[i])
+		i++
+	}
+	return replaced.String()
+}
+
+// unescapePerl replaces the escape sequences of a perl double-quoted string with the characters they represent
+func unescapePerl(text string) string {
+	return perlEscapeRegex.ReplaceAllStringFunc(text, func(escape string) string {
+		m := perlEscapeRegex.FindStringSubmatch(escape)
+		for i, base := range map[int]int{1: 16, 2: 16, 3: 16, 4: 8} {
+			if m[i] != "" {
+				n, err := strconv.ParseInt(m[i], base, 32)
+				if err != nil {
+					return escape
+				}
+				return string(rune(n))
+			}
+		}
+		switch m[5] {
+		case "e":
+			return "\x1b"
+		case "n":
+			return "\n"
+		case "t":
+			return "\t"
+		}
+		return m[5]
+	})
+}
@@ -0,0 +1,96 @@
+package pokesay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	FilterGrayscale    string = "grayscale"
+	FilterSepia        string = "sepia"
+	FilterInvert       string = "invert"
+	FilterSilhouette   string = "silhouette"
+	FilterDeuteranopia string = "deuteranopia"
+)
+
+// a colourFilter changes the RGB values of a colour
+type colourFilter func(r, g, b float64) (float64, float64, float64)
+
+var (
+	// Filters are the colour filters that can be applied to a pokemon with --filter
+	Filters []string = []string{FilterGrayscale, FilterSepia, FilterInvert, FilterSilhouette, FilterDeuteranopia}
+	// SilhouetteColour is the xterm 256 colour that every cell of a pokemon is painted with the silhouette filter
+	SilhouetteColour int = 240
+
+	// the functions that change the RGB values of a colour for each filter
+	filterFuncs map[string]colourFilter = map[string]colourFilter{
+		FilterGrayscale: func(r, g, b float64) (float64, float64, float64) {
+			luma := 0.299*r + 0.587*g + 0.114*b
+			return luma, luma, luma
+		},
+		FilterSepia: func(r, g, b float64) (float64, float64, float64) {
+			return 0.393*r + 0.769*g + 0.189*b, 0.349*r + 0.686*g + 0.168*b, 0.272*r + 0.534*g + 0.131*b
+		},
+		FilterInvert: func(r, g, b float64) (float64, float64, float64) {
+			return 255 - r, 255 - g, 255 - b
+		},
+		FilterSilhouette: func(r, g, b float64) (float64, float64, float64) {
+			sr, sg, sb := xtermRGB(SilhouetteColour)
+			return float64(sr), float64(sg), float64(sb)
+		},
+		FilterDeuteranopia: daltonise,
+	}
+)
+
+// ParseFilter checks that a --filter value is one of Filters. An empty value means no filter
+func ParseFilter(value string) (string, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if _, ok := filterFuncs[value]; value != "" && !ok {
+		return "", fmt.Errorf("invalid filter '%s', expected one of: %s", value, strings.Join(Filters, ", "))
+	}
+	return value, nil
+}
+
+// FilterANSIString changes the FG & BG colours of a sprite with a filter, e.g. to make it grayscale.
+// Default colours & resets are unchanged, so transparent cells stay transparent, and the silhouette
+// filter paints every other cell the same colour.
+// The new colours are the closest xterm 256 colours, so that the sprite looks the same in any terminal
+func FilterANSIString(lines [][]ANSILineToken, filter string) [][]ANSILineToken {
+	fn, ok := filterFuncs[filter]
+	if !ok {
+		return lines
+	}
+	filtered := make(map[string]string)
+	apply := func(code string, prefix string) string {
+		colour := colourOf(code)
+		if colour == "" {
+			return code
+		}
+		if _, ok := filtered[colour]; !ok {
+			r, g, b := fn(colourRGB(colour))
+			filtered[colour] = strconv.Itoa(nearestXterm(r, g, b))
+		}
+		return "\x1b[" + prefix + ";5;" + filtered[colour] + "m"
+	}
+
+	result := make([][]ANSILineToken, len(lines))
+	for i, tokens := range lines {
+		result[i] = make([]ANSILineToken, len(tokens))
+		for j, token := range tokens {
+			result[i][j] = ANSILineToken{FG: apply(token.FG, "38"), BG: apply(token.BG, "48"), T: token.T}
+		}
+	}
+	return result
+}
+
+// daltonise shifts the colours that are hard to tell apart with deuteranopia (red-green colour blindness)
+// towards colours that are easier to tell apart.
+// The colour is simulated as it's seen with deuteranopia, and the difference is moved into the green & blue
+func daltonise(r, g, b float64) (float64, float64, float64) {
+	sr := 0.367322*r + 0.860646*g - 0.227968*b
+	sg := 0.280085*r + 0.672501*g + 0.047413*b
+	sb := -0.011820*r + 0.042940*g + 0.968881*b
+	er, eg, eb := r-sr, g-sg, b-sb
+	return r, g + 0.7*er + eg, b + 0.7*er + eb
+}
@@ -0,0 +1,290 @@
+package pokesay
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/tmck-code/pokesay/src/pokedex"
+)
+
+var (
+	// ListColumns are the columns that can be shown by --list-names
+	ListColumns []string = []string{"id", "name", "japanese", "categories", "size"}
+	// ListFormats are the output formats of --list-names --format, which show the --columns of each sprite, written by WriteList.
+	// Without --format, --list-names prints the {name -> {ID -> categories}} map that it has always printed
+	ListFormats []string = []string{"json", "table", "csv", "plain"}
+
+	// the size categories, from smallest to largest
+	sizes []string = []string{"small", "medium", "big"}
+)
+
+// ChoicesMatching returns a Choice for every sprite of every pokemon whose name contains the query (ignoring case),
+// ordered by name. Only the metadata files of the matching pokemon are read
+func (p *Pokedex) ChoicesMatching(query string) ([]Choice, error) {
+	choices := make([]Choice, 0)
+	for _, name := range p.Search(query) {
+		matches, err := p.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		choices = append(choices, matches...)
+	}
+	return choices, nil
+}
+
+// CategoryFilter is a parsed category expression, used to filter pokemon by their categories.
+// Terms separated by commas must all match, alternatives separated by | match if any of them do,
+// and a leading ! negates a category, e.g.
+// - "gen8,shiny" matches pokemon in both gen8 & shiny
+// - "gen7x|gen8,!shiny" matches pokemon in either gen7x or gen8, that aren't shiny
+type CategoryFilter [][]string
+
+// ParseCategoryFilter parses a category expression. An empty expression matches everything
+func ParseCategoryFilter(expr string) (CategoryFilter, error) {
+	filter := make(CategoryFilter, 0)
+	if strings.TrimSpace(expr) == "" {
+		return filter, nil
+	}
+	for _, term := range strings.Split(expr, ",") {
+		alternatives := make([]string, 0)
+		for _, alternative := range strings.Split(term, "|") {
+			alternative = strings.TrimSpace(alternative)
+			if strings.TrimPrefix(alternative, "!") == "" {
+				return nil, fmt.Errorf("invalid category filter '%s'", expr)
+			}
+			alternatives = append(alternatives, alternative)
+		}
+		filter = append(filter, alternatives)
+	}
+	return filter, nil
+}
+
+// Match returns true if a list of categories matches the filter
+func (filter CategoryFilter) Match(categories []string) bool {
+	for _, alternatives := range filter {
+		matched := false
+		for _, alternative := range alternatives {
+			if category, negated := strings.CutPrefix(alternative, "!"); negated {
+				matched = !slices.Contains(categories, category)
+			} else {
+				matched = slices.Contains(categories, alternative)
+			}
+			if matched {
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the choices that match the filter
+func (filter CategoryFilter) Filter(choices []Choice) []Choice {
+	return slices.DeleteFunc(slices.Clone(choices), func(choice Choice) bool {
+		return !filter.Match(choice.Entry.Categories)
+	})
+}
+
+// ParseColumns parses a comma-separated list of columns, e.g. "id,name,size"
+func ParseColumns(columns string) ([]string, error) {
+	parsed := make([]string, 0)
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(ListColumns, column) {
+			return nil, fmt.Errorf("invalid column '%s', expected one of: %s", column, strings.Join(ListColumns, ", "))
+		}
+		parsed = append(parsed, column)
+	}
+	return parsed, nil
+}
+
+// ColumnValue returns the value of a column for a choice, e.g. "Pikachu" for the name column
+func ColumnValue(choice Choice, column string) string {
+	switch column {
+	case "id":
+		return choice.ID
+	case "name":
+		return choice.Metadata.Name
+	case "japanese":
+		return choice.Metadata.JapaneseName
+	case "categories":
+		return strings.Join(choice.Entry.Categories, ",")
+	case "size":
+		for _, category := range choice.Entry.Categories {
+			if slices.Contains(sizes, category) {
+				return category
+			}
+		}
+	}
+	return ""
+}
+
+// SortChoices sorts choices by a column, in reverse if the column starts with a "-", e.g. "-size".
+// Sizes are sorted from smallest to largest, and all other columns alphabetically
+func SortChoices(choices []Choice, column string) error {
+	column, reverse := strings.CutPrefix(column, "-")
+	if !slices.Contains(ListColumns, column) {
+		return fmt.Errorf("invalid sort column '%s', expected one of: %s", column, strings.Join(ListColumns, ", "))
+	}
+	slices.SortStableFunc(choices, func(a, b Choice) int {
+		var result int
+		if column == "size" {
+			result = cmp.Compare(slices.Index(sizes, ColumnValue(a, column)), slices.Index(sizes, ColumnValue(b, column)))
+		} else {
+			result = cmp.Compare(ColumnValue(a, column), ColumnValue(b, column))
+		}
+		if reverse {
+			return -result
+		}
+		return result
+	})
+	return nil
+}
+
+// NameMap returns the {name -> {ID -> categories}} map of choices, the default --list-names output, e.g.
+//
+//	{"pikachu": {"pikachu/gen8/regular": "small, gen8, regular", "pikachu/gen8/shiny": "small, gen8, shiny"}}
+func (p *Pokedex) NameMap(choices []Choice) map[string]map[string]string {
+	// the names of each pack, by metadata index
+	names := make(map[*pokedex.Pack]map[int]string)
+	for _, pack := range p.Packs {
+		names[pack] = make(map[int]string)
+		for name, idxs := range pack.Names() {
+			for _, idx := range idxs {
+				names[pack][idx] = name
+			}
+		}
+	}
+
+	nameMap := make(map[string]map[string]string)
+	for _, choice := range choices {
+		idx, _ := strconv.Atoi(choice.Metadata.Idx)
+		name, ok := names[choice.Pack][idx]
+		if !ok {
+			name = strings.ToLower(choice.Metadata.Name)
+		}
+		if nameMap[name] == nil {
+			nameMap[name] = make(map[string]string)
+		}
+		nameMap[name][choice.ID] = strings.Join(choice.Entry.Categories, ", ")
+	}
+	return nameMap
+}
+
+// WriteList writes the columns of each choice in one of the ListFormats, using WriteRows
+func WriteList(w io.Writer, format string, columns []string, choices []Choice) error {
+	rows := make([][]string, 0, len(choices))
+	for _, choice := range choices {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = ColumnValue(choice, column)
+		}
+		rows = append(rows, row)
+	}
+	return WriteRows(w, format, columns, rows)
+}
+
+// WriteRows writes rows of column values in one of the ListFormats
+// - json: an array of objects, with a key for each column in order. Categories are an array
+// - table: aligned columns with a header
+// - csv: comma-separated values with a header
+// - plain: tab-separated values without a header, for grep & cut
+func WriteRows(w io.Writer, format string, columns []string, rows [][]string) error {
+	switch format {
+	case "table":
+		writeTable(w, columns, rows)
+	case "json":
+		objects := make([]json.RawMessage, 0, len(rows))
+		for _, row := range rows {
+			object, err := jsonObject(columns, row)
+			if err != nil {
+				return err
+			}
+			objects = append(objects, object)
+		}
+		data, err := json.MarshalIndent(objects, "", strings.Repeat(" ", 2))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(data))
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write(columns)
+		writer.WriteAll(rows)
+		return writer.Error()
+	case "plain":
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+	default:
+		return fmt.Errorf("invalid format '%s', expected one of: %s", format, strings.Join(ListFormats, ", "))
+	}
+	return nil
+}
+
+// jsonObject encodes a row as a JSON object, keeping the keys in the order of the columns (which a map wouldn't)
+func jsonObject(columns []string, row []string) (json.RawMessage, error) {
+	var buf strings.Builder
+	buf.WriteString("{")
+	for i, column := range columns {
+		var value interface{} = row[i]
+		if column == "categories" {
+			// a sprite without categories has an empty array, rather than [""]
+			categories := make([]string, 0)
+			if row[i] != "" {
+				categories = strings.Split(row[i], ",")
+			}
+			value = categories
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(data)
+	}
+	buf.WriteString("}")
+	return json.RawMessage(buf.String()), nil
+}
+
+// writeTable writes rows as a table with a header, padding each column to the display width of its widest value
+func writeTable(w io.Writer, columns []string, rows [][]string) {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = len(column)
+		for _, row := range rows {
+			widths[i] = max(widths[i], runewidth.StringWidth(row[i]))
+		}
+	}
+	writeRow := func(row []string) {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			if i < len(row)-1 {
+				cell = runewidth.FillRight(cell, widths[i])
+			}
+			cells[i] = cell
+		}
+		fmt.Fprintln(w, strings.Join(cells, "  "))
+	}
+	writeRow(columns)
+	for _, row := range rows {
+		writeRow(row)
+	}
+}
@@ -1,13 +1,9 @@
 package pokesay
 
 import (
-	"embed"
-	"errors"
 	"fmt"
 	"io/fs"
-	"log"
 	"math/rand"
-	"path"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +23,34 @@ func RandomInt(n int) int {
 	return rand.New(Rand).Intn(n)
 }
 
+// RandomFloat returns a random number from 0 up to (but not including) 1
+func RandomFloat() float64 {
+	return rand.New(Rand).Float64()
+}
+
+// ChoosePack chooses a random pack, weighted by the number of matching pokemon in each pack
+// (as counted by the weight func), so that every matching pokemon has the same chance of being chosen.
+// If no pack has any matches, the first pack is returned.
+func ChoosePack(packs pokedex.Packs, weight func(*pokedex.Pack) int) *pokedex.Pack {
+	if len(packs) == 1 {
+		return packs[0]
+	}
+	weights := make([]int, len(packs))
+	total := 0
+	for i, pack := range packs {
+		weights[i] = weight(pack)
+		total += weights[i]
+	}
+	choice := RandomInt(total)
+	for i, w := range weights {
+		if choice < w {
+			return packs[i]
+		}
+		choice -= w
+	}
+	return packs[0]
+}
+
 // ChooseByCategory chooses a pokemon via a requested category
 // 1. It loads the category search structure and finds the name of a random Pokemon matching the entry
 // e.g. if given the category "small", this function might pick the file `1.cat` in
@@ -38,85 +62,88 @@ func RandomInt(n int) int {
 // This file contains entries representing the <pokemon metadata index>/<the pokemon entry index>,
 // e.g. "4/1" would represent 4.metadata, and the 2nd entry in that file
 // 2. Using the indexes, load the corresponding metadata file and entry, and then return it
-func ChooseByCategory(category string, categoryDir []fs.DirEntry, categoryFiles embed.FS, categoryRootDir string, metadataFiles embed.FS, metadataRootDir string) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping) {
+func ChooseByCategory(category string, categoryDir []fs.DirEntry, dex pokedex.Pokedex) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
 	if len(categoryDir) == 0 {
-		log.Fatalf("cannot find pokemon by category '%s'", category)
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, fmt.Errorf("cannot find pokemon by category '%s'", category)
 	}
 	choice := categoryDir[RandomInt(len(categoryDir))]
 	timer.DebugTimer.Mark("choose category")
 
-	categoryMetadata, err := categoryFiles.ReadFile(
-		pokedex.CategoryFpath(categoryRootDir, category, choice.Name()),
-	)
-	pokedex.Check(err)
+	categoryMetadata, err := dex.ReadCategoryFile(category, choice.Name())
+	if err != nil {
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, err
+	}
 	timer.DebugTimer.Mark("read category file")
 
-	parts := strings.Split(string(categoryMetadata), "/")
-
-	metadata := pokedex.ReadMetadataFromEmbedded(
-		metadataFiles,
-		path.Join(metadataRootDir, fmt.Sprintf("%s.metadata", parts[0])),
-	)
-
-	entryIndex, err := strconv.Atoi(string(parts[1]))
-	pokedex.Check(err)
+	metadataIndex, entryIndex, ok := strings.Cut(string(categoryMetadata), "/")
+	idx, idxErr := strconv.Atoi(metadataIndex)
+	entryIdx, entryErr := strconv.Atoi(entryIndex)
+	if !ok || idxErr != nil || entryErr != nil {
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, fmt.Errorf("invalid category file %s/%s: '%s'", category, choice.Name(), categoryMetadata)
+	}
+	metadata, err := dex.ReadMetadata(idx)
+	if err != nil {
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, err
+	}
+	if entryIdx < 0 || entryIdx >= len(metadata.Entries) {
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, fmt.Errorf("invalid category file %s/%s: '%s'", category, choice.Name(), categoryMetadata)
+	}
 
-	return metadata, metadata.Entries[entryIndex]
+	return metadata, metadata.Entries[entryIdx], nil
 }
 
 func ListNames(names map[string][]int) []string {
 	return pokedex.GatherMapKeys(names)
 }
 
-func fetchMetadataByName(names map[string][]int, nameToken string, metadataFiles embed.FS, metadataRootDir string) pokedex.PokemonMetadata {
+func fetchMetadataByName(names map[string][]int, nameToken string, dex pokedex.Pokedex) (pokedex.PokemonMetadata, error) {
 	match := names[nameToken]
 	if len(match) == 0 {
-		log.Fatalf("cannot find pokemon by name '%s'", nameToken)
+		return pokedex.PokemonMetadata{}, fmt.Errorf("cannot find pokemon by name '%s'", nameToken)
 	}
 	nameChoice := match[RandomInt(len(match))]
 	timer.DebugTimer.Mark("choose random name")
 
-	metadata := pokedex.ReadMetadataFromEmbedded(
-		metadataFiles,
-		pokedex.MetadataFpath(metadataRootDir, nameChoice),
-	)
-	return metadata
+	metadata, err := dex.ReadMetadata(nameChoice)
+	if err != nil {
+		return metadata, err
+	}
+	if len(metadata.Entries) == 0 {
+		return metadata, fmt.Errorf("pokemon '%s' has no sprites", nameToken)
+	}
+	return metadata, nil
 }
 
-func ChooseByIndex(idx int, entryIdx int, metadataFiles embed.FS, metadataRootDir string) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
-	metadata := pokedex.ReadMetadataFromEmbedded(
-		metadataFiles,
-		pokedex.MetadataFpath(metadataRootDir, idx),
-	)
+func ChooseByIndex(idx int, entryIdx int, dex pokedex.Pokedex) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
+	metadata, err := dex.ReadMetadata(idx)
+	if err != nil {
+		return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, err
+	}
 	for _, entry := range metadata.Entries {
 		if entry.EntryIndex == entryIdx {
 			return metadata, entry, nil
 		}
 	}
-	return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, errors.New("could not find pokemon by index")
+	return pokedex.PokemonMetadata{}, pokedex.PokemonEntryMapping{}, pokedex.ErrIndexNotFound
 }
 
-func ChooseByName(names map[string][]int, nameToken string, metadataFiles embed.FS, metadataRootDir string) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping) {
-	metadata := fetchMetadataByName(
-		names,
-		nameToken,
-		metadataFiles,
-		metadataRootDir,
-	)
+func ChooseByName(names map[string][]int, nameToken string, dex pokedex.Pokedex) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
+	metadata, err := fetchMetadataByName(names, nameToken, dex)
+	if err != nil {
+		return metadata, pokedex.PokemonEntryMapping{}, err
+	}
 
 	// pick a random entry
 	choice := RandomInt(len(metadata.Entries))
-	return metadata, metadata.Entries[choice]
+	return metadata, metadata.Entries[choice], nil
 }
 
-func ChooseByNameAndCategory(names map[string][]int, nameToken string, metadataFiles embed.FS, metadataRootDir string, category string) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping) {
+func ChooseByNameAndCategory(names map[string][]int, nameToken string, dex pokedex.Pokedex, category string) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
 	// fetch the metadata of a pokemon matching the nameToken
-	metadata := fetchMetadataByName(
-		names,
-		nameToken,
-		metadataFiles,
-		metadataRootDir,
-	)
+	metadata, err := fetchMetadataByName(names, nameToken, dex)
+	if err != nil {
+		return metadata, pokedex.PokemonEntryMapping{}, err
+	}
 
 	// now try and find a metadata entry that matches the requested category
 	matching := make([]pokedex.PokemonEntryMapping, 0)
@@ -130,9 +157,9 @@ func ChooseByNameAndCategory(names map[string][]int, nameToken string, metadataF
 
 	// if the category is not found for this pokemon, return a random entry
 	if len(matching) == 0 {
-		return metadata, metadata.Entries[RandomInt(len(metadata.Entries))]
+		return metadata, metadata.Entries[RandomInt(len(metadata.Entries))], nil
 	} else {
-		return metadata, matching[RandomInt(len(matching))]
+		return metadata, matching[RandomInt(len(matching))], nil
 	}
 }
 
@@ -0,0 +1,169 @@
+package pokesay
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ManPage is the content of the pokesay man page that comes from the program itself,
+// so that the man page always matches the flags, commands & categories
+type ManPage struct {
+	Version    string
+	Date       string // the date of the release, e.g. "September 2025"
+	Flags      []FlagInfo
+	Commands   []CommandInfo
+	Categories []string
+}
+
+// ManExample is an example in the EXAMPLES section of the man page
+type ManExample struct {
+	Description string
+	Commands    []string
+}
+
+// ManExamples are the examples shown in the man page
+var ManExamples []ManExample = []ManExample{
+	{"Print a message with a random pokemon:", []string{"pokesay Hello, world!", "echo 'Hello, world!' | pokesay"}},
+	{"Print a message from a file:", []string{"pokesay --file message.txt"}},
+	{"See a random fortune every time you open a terminal (requires fortune):", []string{"echo 'fortune | pokesay' >> $HOME/.bashrc"}},
+	{"Print a message with a specific pokemon:", []string{"echo 'Hello, world!' | pokesay -n pikachu"}},
+	{"Print a message with a pokemon from a category, or a combination of categories:", []string{
+		"echo 'Hello, world!' | pokesay -c big",
+		"echo 'Hello, world!' | pokesay -c gen8,shiny",
+	}},
+	{"Print a message with a specific pokemon category and name:", []string{"echo 'Hello, world!' | pokesay -c shiny -n charizard"}},
+	{"Print a specific pokemon by its ID:", []string{"echo 'Hello, world!' | pokesay -i pikachu/gen8/shiny"}},
+	{"Print a random pokemon that is only shiny as often as in the games:", []string{"echo 'Hello, world!' | pokesay --shiny-odds 1/4096"}},
+	{"Print a pokemon with its evolutions, side by side:", []string{"echo 'Hello, world!' | pokesay -n charmander --evolutions"}},
+	{"Print a pokemon from the original 151, with its dex info:", []string{"echo 'Hello, world!' | pokesay -d 1-151 -D"}},
+	{"List all names and categories:", []string{"pokesay list names", "pokesay list categories"}},
+	{"List the small shiny pokemon as JSON:", []string{"pokesay -l -c small,shiny --format json"}},
+	{"Print the information about a pokemon:", []string{"pokesay info pikachu"}},
+	{"Cycle through the sprites of a pokemon until a key is pressed:", []string{"pokesay --animate -n pikachu --interval 500ms"}},
+	{"Guess who's that pokemon, from the original 151:", []string{"pokesay quiz -d 1-151"}},
+	{"Install the bash completion script:", []string{"pokesay completion bash > ~/.local/share/bash-completion/completions/pokesay"}},
+}
+
+// roffEscape escapes text for roff, so that backslashes & dashes are printed as-is,
+// and lines that start with a . or ' aren't read as requests
+func roffEscape(text string) string {
+	text = strings.NewReplacer(`\`, `\e`, `-`, `\-`).Replace(text)
+	if strings.HasPrefix(text, ".") || strings.HasPrefix(text, "'") {
+		text = `\&` + text
+	}
+	return text
+}
+
+// roffSentence capitalises the first letter of a help message, and ends it with a full stop
+func roffSentence(text string) string {
+	if text == "" {
+		return text
+	}
+	text = strings.ToUpper(text[:1]) + text[1:]
+	if !strings.HasSuffix(text, ".") {
+		text += "."
+	}
+	return roffEscape(text)
+}
+
+// flagSynopsis returns the roff for the names of a flag and its value, e.g. -n, --name=VALUE
+func (flag FlagInfo) flagSynopsis() string {
+	names := make([]string, 0, 2)
+	for _, name := range flag.flagNames() {
+		names = append(names, `\fB`+roffEscape(name)+`\fR`)
+	}
+	synopsis := strings.Join(names, ", ")
+	value := `\fI` + roffEscape(strings.ToUpper(flag.Value)) + `\fR`
+	switch {
+	case flag.Value == "":
+	case flag.Optional:
+		synopsis += "[=" + value + "]"
+	default:
+		synopsis += "=" + value
+	}
+	return synopsis
+}
+
+// WriteManPage writes the pokesay man page in roff
+func WriteManPage(w io.Writer, page ManPage) {
+	fmt.Fprintf(w, ".TH POKESAY 1 \"%s\" \"pokesay %s\" \"User Commands\"\n", page.Date, page.Version)
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintln(w, `pokesay \- print Pokémon in the CLI! An adaptation of the classic "cowsay"`)
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintln(w, ".B pokesay")
+	fmt.Fprintln(w, `[\fIOPTIONS\fR] [\fIMESSAGE\fR ... | \- | \fICOMMAND\fR [\fIPARAMETERS\fR ...]]`)
+
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	fmt.Fprintln(w, ".B pokesay")
+	fmt.Fprintln(w, "displays a message in a speech bubble spoken by a Pokémon sprite.")
+	fmt.Fprintln(w, `The message is read from the parameters, the \fB\-\-file\fR, or else from stdin (also with a parameter of \fB\-\fR).`)
+	fmt.Fprintln(w, "The Pokémon is chosen at random, or by its name, ID, category or national dex number.")
+
+	fmt.Fprintln(w, ".SH OPTIONS")
+	for _, flag := range page.Flags {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintln(w, flag.flagSynopsis())
+		help := roffSentence(flag.Help)
+		if flag.Default != "" {
+			help += " [default: " + roffEscape(flag.Default) + "]"
+		}
+		fmt.Fprintln(w, help)
+	}
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	fmt.Fprintln(w, "When the first parameter isn't a command, the parameters are the message and a random Pokémon is printed.")
+	for _, command := range page.Commands {
+		fmt.Fprintln(w, ".TP")
+		synopsis := `\fB` + command.Name + `\fR`
+		if command.Usage != "" {
+			synopsis += ` \fI` + roffEscape(command.Usage) + `\fR`
+		}
+		fmt.Fprintln(w, synopsis)
+		fmt.Fprintln(w, roffSentence(command.Help))
+	}
+
+	fmt.Fprintln(w, ".SH CATEGORIES")
+	fmt.Fprintln(w, "Every Pokémon sprite belongs to a number of categories, which can be chosen with")
+	fmt.Fprintln(w, `\fB\-c\fR, \fB\-\-category\fR:`)
+	fmt.Fprintln(w, ".PP")
+	fmt.Fprintln(w, roffEscape(strings.Join(page.Categories, ", ")))
+
+	fmt.Fprintln(w, ".SH EXAMPLES")
+	for i, example := range ManExamples {
+		if i > 0 {
+			fmt.Fprintln(w, ".PP")
+		}
+		fmt.Fprintln(w, roffEscape(example.Description))
+		fmt.Fprintln(w, ".PP")
+		fmt.Fprintln(w, ".EX")
+		for _, command := range example.Commands {
+			fmt.Fprintln(w, "    "+roffEscape(command))
+		}
+		fmt.Fprintln(w, ".EE")
+	}
+
+	fmt.Fprintln(w, ".SH ENVIRONMENT")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, `\fBPOKESAY_\fR\fIOPTION\fR`)
+	fmt.Fprintln(w, `The default value of an option, e.g. \fBPOKESAY_BORDER_STYLE=rounded\fR. Options given on the command line take precedence.`)
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, `\fBPOKESAY_PACKS\fR`)
+	fmt.Fprintln(w, "A list of directories to load extra sprite packs from, separated by colons.")
+
+	fmt.Fprintln(w, ".SH FILES")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, `\fI$XDG_CONFIG_HOME/pokesay/config.toml\fR (or \fI~/.config/pokesay/config.toml\fR)`)
+	fmt.Fprintln(w, "The default values of options, and user-defined border styles. Environment variables take precedence.")
+	fmt.Fprintln(w, ".TP")
+	fmt.Fprintln(w, `\fI$XDG_STATE_HOME/pokesay/quiz.json\fR (or \fI~/.local/state/pokesay/quiz.json\fR)`)
+	fmt.Fprintln(w, `The score of \fBpokesay quiz\fR, e.g. the streak of correct guesses.`)
+
+	fmt.Fprintln(w, ".SH AUTHOR")
+	fmt.Fprintln(w, "Tom McKeesick <tmck01@gmail.com>")
+	fmt.Fprintln(w, ".SH SEE ALSO")
+	fmt.Fprintln(w, ".BR cowsay (1)")
+	fmt.Fprintln(w, ".SH HOMEPAGE")
+	fmt.Fprintln(w, "https://github.com/tmck-code/pokesay")
+}
@@ -0,0 +1,63 @@
+package pokesay
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// matches an escape sequence at the start of a string: a CSI sequence (e.g. "\x1b[2J"),
+	// an OSC sequence (e.g. a terminal title or hyperlink), or any other 2 byte escape.
+	// A lone escape character also matches, so that it can be removed
+	escapeSequenceRegex *regexp.Regexp = regexp.MustCompile(`^\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)?|[@-_])?`)
+	// matches an SGR escape sequence, which sets the text colour or style, e.g. "\x1b[38;5;208m"
+	sgrSequenceRegex *regexp.Regexp = regexp.MustCompile(`^\x1b\[[0-9;:]*m$`)
+)
+
+// ReadLines calls fn with each line of a message, without its line ending (\n or \r\n) and sanitised by SanitiseLine.
+// Unlike a bufio.Scanner, there is no limit on the length of a line, so e.g. minified JSON is read in full
+func ReadLines(r io.Reader, fn func(line string)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			fn(SanitiseLine(line))
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// SanitiseLine makes a line of a message safe to print inside the speech bubble
+// - invalid UTF-8 is replaced with the unicode replacement character (�)
+// - SGR escape sequences (text colours & styles) are kept, and all other escape sequences are removed
+// - all other control characters (except tabs) are removed, e.g. carriage returns, backspaces & bells
+func SanitiseLine(line string) string {
+	line = strings.ToValidUTF8(line, string(utf8.RuneError))
+
+	var sanitised strings.Builder
+	for i := 0; i < len(line); {
+		if line[i] == '\x1b' {
+			sequence := escapeSequenceRegex.FindString(line[i:])
+			if sgrSequenceRegex.MatchString(sequence) {
+				sanitised.WriteString(sequence)
+			}
+			i += len(sequence)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		if r == '\t' || !unicode.IsControl(r) {
+			sanitised.WriteRune(r)
+		}
+		i += size
+	}
+	return sanitised.String()
+}
@@ -0,0 +1,456 @@
+package pokesay
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tmck-code/pokesay/src/pokedex"
+	"github.com/tmck-code/pokesay/src/timer"
+)
+
+// Pokedex is the main entry point for other Go programs that use pokesay as a library.
+// It is constructed once from a source of assets (e.g. the assets embedded in the pokesay binary),
+// and any number of extra packs, and is used to choose pokemon & read their sprites.
+//
+//	dex, err := pokesay.NewPokedex(os.DirFS("build/assets"), ".")
+//	choice, err := dex.ByName("pikachu")
+//	sprite, err := dex.Sprite(choice)
+//	fmt.Print(string(sprite))
+type Pokedex struct {
+	Packs pokedex.Packs
+}
+
+// Choice is a single pokemon sprite that was chosen from a Pokedex
+type Choice struct {
+	ID       string // the stable ID of the sprite (e.g. pikachu/gen8/shiny), or <metadata index>.<entry index> for packs without IDs
+	Metadata pokedex.PokemonMetadata
+	Entry    pokedex.PokemonEntryMapping
+	Pack     *pokedex.Pack // the pack that the sprite belongs to
+}
+
+// NewPokedex creates a Pokedex from assets stored under root in fsys, in the same layout as build/assets
+func NewPokedex(fsys fs.FS, root string) (*Pokedex, error) {
+	pack, err := pokedex.NewPack("embedded", fsys, root)
+	if err != nil {
+		return nil, err
+	}
+	return &Pokedex{Packs: pokedex.Packs{pack}}, nil
+}
+
+// AddPack merges an extra pack into the Pokedex, after all existing packs
+func (p *Pokedex) AddPack(pack *pokedex.Pack) {
+	p.Packs = append(p.Packs, pack)
+}
+
+// LoadPackDirs reads packs from directories on disk, and merges them into the Pokedex
+func (p *Pokedex) LoadPackDirs(dirpaths []string) error {
+	for _, dirpath := range dirpaths {
+		pack, err := pokedex.LoadPackDir(dirpath)
+		if err != nil {
+			return err
+		}
+		p.AddPack(pack)
+	}
+	return nil
+}
+
+// newChoice creates a Choice, using the stable ID of the entry if it has one,
+// or the merged metadata index of the pokemon otherwise
+func (p *Pokedex) newChoice(pack *pokedex.Pack, metadata pokedex.PokemonMetadata, entry pokedex.PokemonEntryMapping) Choice {
+	id := entry.ID
+	if id == "" {
+		idx, _ := strconv.Atoi(metadata.Idx)
+		id = pokedex.IndexID(p.Packs.Offset(pack)+idx, entry.EntryIndex)
+	}
+	return Choice{
+		ID:       id,
+		Metadata: metadata,
+		Entry:    entry,
+		Pack:     pack,
+	}
+}
+
+// Names returns the sorted names of all pokemon in the Pokedex
+func (p *Pokedex) Names() []string {
+	return pokedex.GatherMapKeys(p.Packs.Names())
+}
+
+// Categories returns the sorted, unique categories of all pokemon in the Pokedex
+func (p *Pokedex) Categories() []string {
+	return p.Packs.CategoryKeys()
+}
+
+// Random chooses a random pokemon
+// - This generates a random number between 0 and the total number of pokemon in all packs
+// - finds the pack containing that index, and reads the metadata file at `<index>.metadata`
+// - chooses a random entry from the metadata file
+func (p *Pokedex) Random() (Choice, error) {
+	pack, idx, err := p.Packs.Locate(RandomInt(p.Packs.Total()))
+	if err != nil {
+		return Choice{}, err
+	}
+	timer.DebugTimer.Mark("choose index")
+
+	metadata, entry, err := chooseRandomEntry(pack, idx)
+	if err != nil {
+		return Choice{}, err
+	}
+	timer.DebugTimer.Mark("choose entry")
+
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// chooseRandomEntry reads the metadata file of a pokemon in a pack, and chooses a random entry
+func chooseRandomEntry(pack *pokedex.Pack, idx int) (pokedex.PokemonMetadata, pokedex.PokemonEntryMapping, error) {
+	metadata, err := pack.ReadMetadata(idx)
+	if err != nil {
+		return metadata, pokedex.PokemonEntryMapping{}, fmt.Errorf("could not read pack '%s': %w", pack.Name, err)
+	}
+	if len(metadata.Entries) == 0 {
+		return metadata, pokedex.PokemonEntryMapping{}, fmt.Errorf("pokemon '%s' has no sprites", metadata.Name)
+	}
+	return metadata, metadata.Entries[RandomInt(len(metadata.Entries))], nil
+}
+
+// ByName chooses a random sprite of the pokemon with a name.
+// The name must match the lowercase name of the pokemon
+func (p *Pokedex) ByName(name string) (Choice, error) {
+	pack := ChoosePack(p.Packs, func(pack *pokedex.Pack) int { return len(pack.Names()[name]) })
+	if len(pack.Names()[name]) == 0 {
+		return Choice{}, fmt.Errorf("cannot find pokemon by name '%s'", name)
+	}
+	timer.DebugTimer.Mark("read name struct")
+
+	metadata, entry, err := ChooseByName(pack.Names(), name, pack.Pokedex)
+	if err != nil {
+		return Choice{}, err
+	}
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// ByNameAndCategory chooses a random sprite of the pokemon with a name, that is in a category.
+// If the pokemon has no sprites in the category, a random sprite of the pokemon is chosen instead
+func (p *Pokedex) ByNameAndCategory(name string, category string) (Choice, error) {
+	pack := ChoosePack(p.Packs, func(pack *pokedex.Pack) int { return len(pack.Names()[name]) })
+	if len(pack.Names()[name]) == 0 {
+		return Choice{}, fmt.Errorf("cannot find pokemon by name '%s'", name)
+	}
+	timer.DebugTimer.Mark("read name struct")
+
+	metadata, entry, err := ChooseByNameAndCategory(pack.Names(), name, pack.Pokedex, category)
+	if err != nil {
+		return Choice{}, err
+	}
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// ByID chooses the sprite with an ID, which can be
+// - a stable ID, e.g. pikachu/gen8/shiny
+// - a numeric ID of <metadata index>.<entry index>, e.g. 0025.0123
+// - a metadata index, e.g. 0025, which chooses a random sprite of that pokemon
+//
+// If there is no sprite with the ID, the error lists the nearest valid IDs
+func (p *Pokedex) ByID(id string) (Choice, error) {
+	if strings.Contains(id, "/") {
+		pack, idx, entryIdx, err := p.Packs.FindID(id)
+		if err != nil {
+			return Choice{}, notFoundError(id, p.nearestStableIDs(id))
+		}
+		return p.choose(pack, idx, entryIdx)
+	}
+	idx, entryIdx, err := ParseID(id)
+	if err != nil {
+		return Choice{}, err
+	}
+	if idx >= p.Packs.Total() {
+		return Choice{}, notFoundError(id, p.nearestIDs(idx, entryIdx))
+	}
+	if entryIdx < 0 {
+		pack, packIdx, err := p.Packs.Locate(idx)
+		if err != nil {
+			return Choice{}, err
+		}
+		metadata, entry, err := chooseRandomEntry(pack, packIdx)
+		if err != nil {
+			return Choice{}, err
+		}
+		return p.newChoice(pack, metadata, entry), nil
+	}
+	choice, err := p.ByIndex(idx, entryIdx)
+	if errors.Is(err, pokedex.ErrIndexNotFound) {
+		return Choice{}, notFoundError(id, p.nearestIDs(idx, entryIdx))
+	}
+	return choice, err
+}
+
+// ParseID parses a numeric ID of the form NNNN or NNNN.MMMM into a metadata index and entry index.
+// If the ID has no entry index, -1 is returned as the entry index
+func ParseID(id string) (int, int, error) {
+	idxToken, entryToken, hasEntry := strings.Cut(strings.TrimSpace(id), ".")
+	idx, err := strconv.Atoi(idxToken)
+	if err != nil || idx < 0 {
+		return 0, 0, fmt.Errorf("invalid ID '%s', expected NNNN, NNNN.MMMM or an ID like pikachu/gen8/shiny", id)
+	}
+	if !hasEntry {
+		return idx, -1, nil
+	}
+	entryIdx, err := strconv.Atoi(entryToken)
+	if err != nil || entryIdx < 0 {
+		return 0, 0, fmt.Errorf("invalid ID '%s', expected NNNN, NNNN.MMMM or an ID like pikachu/gen8/shiny", id)
+	}
+	return idx, entryIdx, nil
+}
+
+// notFoundError creates the error for an ID that doesn't match any sprite, listing the nearest valid IDs
+func notFoundError(id string, nearest []string) error {
+	if len(nearest) == 0 {
+		return fmt.Errorf("could not find pokemon by ID '%s'", id)
+	}
+	return fmt.Errorf("could not find pokemon by ID '%s', the nearest IDs are:\n  %s", id, strings.Join(nearest, "\n  "))
+}
+
+// nearestIDs returns up to 3 valid IDs of the entries that are nearest to <idx>.<entryIdx>,
+// from the pokemon with the nearest metadata index.
+// Stable IDs are used where possible, as numeric IDs might be mapped to other sprites by the legacy IDs
+func (p *Pokedex) nearestIDs(idx int, entryIdx int) []string {
+	idx = min(idx, p.Packs.Total()-1)
+	pack, packIdx, err := p.Packs.Locate(idx)
+	if err != nil {
+		return nil
+	}
+	metadata, err := pack.ReadMetadata(packIdx)
+	if err != nil {
+		return nil
+	}
+	entries := slices.Clone(metadata.Entries)
+	slices.SortStableFunc(entries, func(a, b pokedex.PokemonEntryMapping) int {
+		return cmp.Compare(abs(a.EntryIndex-entryIdx), abs(b.EntryIndex-entryIdx))
+	})
+
+	nearest := make([]string, 0, 3)
+	for _, entry := range entries[:min(3, len(entries))] {
+		if entry.ID != "" {
+			nearest = append(nearest, entry.ID)
+		} else {
+			nearest = append(nearest, pokedex.IndexID(idx, entry.EntryIndex))
+		}
+	}
+	return nearest
+}
+
+// nearestStableIDs returns up to 3 stable IDs of the pokemon in a stable ID,
+// e.g. for "pikachu/gen9/shiny", the IDs starting with "pikachu/"
+func (p *Pokedex) nearestStableIDs(id string) []string {
+	name, _, _ := strings.Cut(id, "/")
+	matches := make([]string, 0)
+	for _, pack := range p.Packs {
+		for stableID := range pack.IDs() {
+			if strings.HasPrefix(stableID, name+"/") {
+				matches = append(matches, stableID)
+			}
+		}
+	}
+	slices.Sort(matches)
+	return matches[:min(3, len(matches))]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ByIndex chooses the sprite with a numeric ID of <metadata index>.<entry index>.
+// Numeric IDs from previous builds of a pack are looked up in its legacy IDs first,
+// so that they still point at the same sprite after the pack is rebuilt
+func (p *Pokedex) ByIndex(idx int, entryIdx int) (Choice, error) {
+	pack, packIdx, err := p.Packs.Locate(idx)
+	if err != nil {
+		return Choice{}, err
+	}
+	if id, ok := pack.LegacyIDs()[pokedex.IndexID(packIdx, entryIdx)]; ok {
+		idxs, ok := pack.IDs()[id]
+		if !ok {
+			return Choice{}, fmt.Errorf("pokemon with ID '%s' is no longer available", id)
+		}
+		packIdx, entryIdx = idxs[0], idxs[1]
+	}
+	return p.choose(pack, packIdx, entryIdx)
+}
+
+// choose reads the sprite with a metadata & entry index within a pack
+func (p *Pokedex) choose(pack *pokedex.Pack, idx int, entryIdx int) (Choice, error) {
+	metadata, entry, err := ChooseByIndex(idx, entryIdx, pack.Pokedex)
+	if err != nil {
+		return Choice{}, err
+	}
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// ByDex chooses a random sprite of a pokemon with a national dex number between lo and hi (inclusive).
+// Each pokemon in the range has the same chance of being chosen, regardless of how many sprites it has
+func (p *Pokedex) ByDex(lo int, hi int) (Choice, error) {
+	idxs := p.Packs.DexRange(lo, hi)
+	if len(idxs) == 0 {
+		if lo == hi {
+			return Choice{}, fmt.Errorf("cannot find pokemon by national dex number %d", lo)
+		}
+		return Choice{}, fmt.Errorf("cannot find pokemon by national dex numbers %d-%d", lo, hi)
+	}
+	pack, idx, err := p.Packs.Locate(idxs[RandomInt(len(idxs))])
+	if err != nil {
+		return Choice{}, err
+	}
+	timer.DebugTimer.Mark("choose dex number")
+
+	metadata, entry, err := chooseRandomEntry(pack, idx)
+	if err != nil {
+		return Choice{}, err
+	}
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// ParseDexRange parses a national dex number (e.g. "25") or range of numbers (e.g. "1-151")
+func ParseDexRange(token string) (int, int, error) {
+	loToken, hiToken, isRange := strings.Cut(token, "-")
+	lo, err := strconv.Atoi(strings.TrimSpace(loToken))
+	if err != nil || lo < 1 {
+		return 0, 0, fmt.Errorf("invalid national dex number '%s'", token)
+	}
+	if !isRange {
+		return lo, lo, nil
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(hiToken))
+	if err != nil || hi < lo {
+		return 0, 0, fmt.Errorf("invalid national dex number range '%s'", token)
+	}
+	return lo, hi, nil
+}
+
+// ByCategory chooses a random sprite in a category
+func (p *Pokedex) ByCategory(category string) (Choice, error) {
+	pack := ChoosePack(p.Packs, func(pack *pokedex.Pack) int { return len(pack.ReadCategoryDir(category)) })
+	dir := pack.ReadCategoryDir(category)
+	if len(dir) == 0 {
+		return Choice{}, fmt.Errorf("cannot find pokemon by category '%s'", category)
+	}
+
+	metadata, entry, err := ChooseByCategory(category, dir, pack.Pokedex)
+	if err != nil {
+		return Choice{}, err
+	}
+	return p.newChoice(pack, metadata, entry), nil
+}
+
+// Lookup returns a Choice for every sprite of the pokemon with a name.
+// The name must match the lowercase name of the pokemon
+func (p *Pokedex) Lookup(name string) ([]Choice, error) {
+	choices := make([]Choice, 0)
+	for _, pack := range p.Packs {
+		for _, idx := range pack.Names()[name] {
+			metadata, err := pack.ReadMetadata(idx)
+			if err != nil {
+				return nil, fmt.Errorf("could not read pack '%s': %w", pack.Name, err)
+			}
+			choices = append(choices, p.choicesOf(pack, metadata)...)
+		}
+	}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("cannot find pokemon by name '%s'", name)
+	}
+	return choices, nil
+}
+
+// Choices returns a Choice for every sprite in the Pokedex, in metadata index order.
+// This reads every metadata file, so is much slower than choosing a single pokemon
+func (p *Pokedex) Choices() ([]Choice, error) {
+	choices := make([]Choice, 0)
+	for _, pack := range p.Packs {
+		for idx := 0; idx < pack.Total; idx++ {
+			metadata, err := pack.ReadMetadata(idx)
+			if err != nil {
+				return nil, fmt.Errorf("could not read pack '%s': %w", pack.Name, err)
+			}
+			choices = append(choices, p.choicesOf(pack, metadata)...)
+		}
+	}
+	return choices, nil
+}
+
+// Search returns the sorted names of all pokemon that contain the query (ignoring case)
+func (p *Pokedex) Search(query string) []string {
+	query = strings.ToLower(query)
+	matches := make([]string, 0)
+	for _, name := range p.Names() {
+		if strings.Contains(name, query) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// Sprites returns a Choice for every sprite of the same pokemon as a choice
+func (p *Pokedex) Sprites(choice Choice) []Choice {
+	return p.choicesOf(choice.Pack, choice.Metadata)
+}
+
+// choicesOf returns a Choice for every entry of a pokemon
+func (p *Pokedex) choicesOf(pack *pokedex.Pack, metadata pokedex.PokemonMetadata) []Choice {
+	choices := make([]Choice, 0, len(metadata.Entries))
+	for _, entry := range metadata.Entries {
+		choices = append(choices, p.newChoice(pack, metadata, entry))
+	}
+	return choices
+}
+
+// Sprite returns the sprite of a chosen pokemon, as text with ANSI colour codes
+func (p *Pokedex) Sprite(choice Choice) ([]byte, error) {
+	sprite, err := choice.Pack.ReadCow(choice.Entry.EntryIndex)
+	if err != nil {
+		return nil, fmt.Errorf("could not read pack '%s': %w", choice.Pack.Name, err)
+	}
+	return sprite, nil
+}
+
+// Evolutions returns the evolution lines that a chosen pokemon is in, e.g. charmander -> charmeleon -> charizard.
+// The chosen pokemon keeps its sprite, and every other pokemon has the sprite that shares the most categories with it,
+// e.g. the gen8 shiny sprites for a gen8 shiny charmander.
+// Pokemon that aren't in the Pokedex are skipped, and so are lines that are left with less than 2 pokemon
+func (p *Pokedex) Evolutions(choice Choice) [][]Choice {
+	shared := func(sprite Choice) int {
+		n := 0
+		for _, category := range sprite.Entry.Categories {
+			if slices.Contains(choice.Entry.Categories, category) {
+				n++
+			}
+		}
+		return n
+	}
+	lines := make([][]Choice, 0, len(choice.Metadata.Evolutions))
+	for _, names := range choice.Metadata.Evolutions {
+		line := make([]Choice, 0, len(names))
+		for _, name := range names {
+			sprites, err := p.Lookup(name)
+			if err != nil {
+				continue
+			}
+			if slices.ContainsFunc(sprites, func(sprite Choice) bool {
+				return sprite.Pack == choice.Pack && sprite.Metadata.Idx == choice.Metadata.Idx
+			}) {
+				line = append(line, choice)
+			} else {
+				line = append(line, slices.MaxFunc(sprites, func(a Choice, b Choice) int { return cmp.Compare(shared(a), shared(b)) }))
+			}
+		}
+		if len(line) > 1 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -1,11 +1,12 @@
 package pokesay
 
 import (
-	"bufio"
-	"embed"
+	"cmp"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-runewidth"
@@ -38,15 +39,39 @@ type Args struct {
 	ListCategories bool
 	ListNames      bool
 	ListNameToken  string
+	ListColumns    []string // the columns to show with --list-names
+	ListSort       string   // the column to sort --list-names by
 	Category       string
 	NameToken      string
 	IDToken        string
+	DexToken       string
+	ShinyOdds      float64 // the chance that a chosen pokemon is shiny (e.g. 1/4096), or 0 to choose shiny & regular sprites equally
 	JapaneseName   bool
 	ShowID         bool
+	DexInfo        bool
 	BoxChars       *BoxChars
+	BubbleColour   string // the ANSI colour of the bubble & info box borders, or AutoColour
+	TextColour     string // the ANSI colour of the bubble text
 	DrawInfoBorder bool
-	FlipPokemon    bool
+	Scale          float64       // the factor to resize the pokemon by, or 0 (or 1) for its original size
+	MaxHeight      int           // the max height of the pokemon in lines (it's shrunk to fit), or 0 for no limit
+	Filter         string        // the colour filter to apply to the pokemon, one of Filters, or "" for none
+	Animate        string        // redraw the pokemon in place, one of AnimateModes, or "" to print it once
+	Interval       time.Duration // the time between the frames of Animate
+	Frames         int           // the number of frames to animate, or 0 to animate until a key is pressed
+	Flip           string        // the direction to flip the pokemon: "", FlipHorizontal, FlipVertical or FlipBoth
+	Evolutions     bool          // print the evolution lines of the pokemon side by side, instead of just the pokemon
+	NoTether       bool          // don't draw the tether below the speech bubble, as the sprite draws its own (e.g. a cowfile)
+	ExportCow      string        // the name or ID of a pokemon to print as a cowfile
+	Cowfile        string        // the path of a cowfile to print instead of a pokemon
+	Packs          []string
+	Writer         io.Writer // where the bubble & pokemon are printed, or nil for stdout
+	Message        io.Reader // the text to print in the speech bubble, or nil to print the pokemon without a bubble
+	Command        string    // the subcommand, e.g. "list", or "" to print a pokemon
+	CommandArgs    []string  // the parameters of the subcommand, e.g. ["names"]
+	Format         string    // the output format of --list-names & subcommands, or "" for the default
 	Help           bool
+	GenerateMan    bool
 	Verbose        bool
 }
 
@@ -86,6 +111,14 @@ var (
 	}
 )
 
+// output returns the writer to print to, which is stdout unless args.Writer is set
+func (args Args) output() io.Writer {
+	if args.Writer == nil {
+		return os.Stdout
+	}
+	return args.Writer
+}
+
 func DetermineBoxChars(unicodeBox bool) *BoxChars {
 	if unicodeBox {
 		return UnicodeBoxChars
@@ -94,31 +127,47 @@ func DetermineBoxChars(unicodeBox bool) *BoxChars {
 	}
 }
 
-// The main print function! This uses a chosen pokemon's index, names and categories, and an
-// embedded filesystem of cowfile data
-// 1. The text received from STDIN is printed inside a speech bubble
-// 2. The cowfile data is retrieved using the matching index, decompressed (un-gzipped),
+// The main print function! This uses a chosen pokemon's index, names and categories, and the
+// pokedex containing the cowfile data (e.g. the embedded assets, or a pack loaded at runtime)
+// 1. The cowfile data is retrieved using the matching index, decompressed (un-gzipped),
+// 2. The message (e.g. received from STDIN) is printed inside a speech bubble
 // 3. The pokemon is printed along with the name & category information
-func Print(args Args, choice int, names []string, categories []string, cows embed.FS) {
-	printSpeechBubble(args.BoxChars, bufio.NewScanner(os.Stdin), args)
+func Print(args Args, choice int, names []string, categories []string, dex pokedex.Pokedex) error {
+	dec, err := dex.ReadCow(choice)
+	if err != nil {
+		return err
+	}
+	timer.DebugTimer.Mark("read sprite file")
 
-	printPokemon(args, choice, names, categories, cows)
+	PrintSprite(args, dec, names, categories)
+	return nil
 }
 
-// Prints text from STDIN, surrounded by a speech bubble.
-func printSpeechBubble(boxChars *BoxChars, scanner *bufio.Scanner, args Args) {
+// PrintSprite prints the message inside a speech bubble, followed by a sprite (e.g. from a cowfile)
+// and its name & category information
+func PrintSprite(args Args, sprite []byte, names []string, categories []string) {
+	if args.BubbleColour == AutoColour {
+		args.BubbleColour = DominantColour(FilterANSIString(TokeniseANSIString(string(sprite)), args.Filter))
+		timer.DebugTimer.Mark("find dominant colour")
+	}
+	if args.Message != nil {
+		printSpeechBubble(args.BoxChars, args.Message, args)
+	}
+
+	printPokemon(args, sprite, names, categories)
+}
+
+// Prints the message text, surrounded by a speech bubble.
+func printSpeechBubble(boxChars *BoxChars, message io.Reader, args Args) {
 	if args.DrawBubble {
-		fmt.Printf(
-			"%s%s%s\n",
-			boxChars.TopLeftCorner,
-			strings.Repeat(boxChars.HorizontalEdge, args.Width+2),
-			boxChars.TopRightCorner,
+		fmt.Fprintf(
+			args.output(),
+			"%s\n",
+			paint(args.BubbleColour, boxChars.TopLeftCorner+strings.Repeat(boxChars.HorizontalEdge, args.Width+2)+boxChars.TopRightCorner),
 		)
 	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-
+	err := ReadLines(message, func(line string) {
 		if !args.NoTabSpaces {
 			line = strings.Replace(line, "\t", args.TabSpaces, -1)
 		}
@@ -127,20 +176,23 @@ func printSpeechBubble(boxChars *BoxChars, scanner *bufio.Scanner, args Args) {
 		} else {
 			printWrappedText(boxChars, line, args)
 		}
-	}
-	timer.DebugTimer.Mark("scan stdin")
+	})
+	pokedex.Check(err)
+	timer.DebugTimer.Mark("scan message")
 
 	bottomBorder := strings.Repeat(boxChars.HorizontalEdge, 6) +
 		boxChars.BalloonTether +
 		strings.Repeat(boxChars.HorizontalEdge, args.Width+2-7)
 
 	if args.DrawBubble {
-		fmt.Printf("%s%s%s\n", boxChars.BottomLeftCorner, bottomBorder, boxChars.BottomRightCorner)
+		fmt.Fprintf(args.output(), "%s\n", paint(args.BubbleColour, boxChars.BottomLeftCorner+bottomBorder+boxChars.BottomRightCorner))
 	} else {
-		fmt.Printf(" %s \n", bottomBorder)
+		fmt.Fprintf(args.output(), " %s \n", paint(args.BubbleColour, bottomBorder))
 	}
-	for i := 0; i < 4; i++ {
-		fmt.Printf("%s%s\n", strings.Repeat(" ", i+8), boxChars.BalloonString)
+	if !args.NoTether {
+		for i := 0; i < cowTetherLines; i++ {
+			fmt.Fprintf(args.output(), "%s%s\n", strings.Repeat(" ", i+8), paint(args.BubbleColour, boxChars.BalloonString))
+		}
 	}
 	timer.DebugTimer.Mark("print speech bubble")
 }
@@ -148,26 +200,29 @@ func printSpeechBubble(boxChars *BoxChars, scanner *bufio.Scanner, args Args) {
 // Prints a single speech bubble line
 func printSpeechBubbleLine(boxChars *BoxChars, line string, args Args) {
 	if !args.DrawBubble {
-		fmt.Println(line)
+		fmt.Fprintln(args.output(), paint(args.TextColour, line))
 		return
 	}
+	edge := paint(args.BubbleColour, boxChars.VerticalEdge)
 
 	lineLen := UnicodeStringLength(line)
 	if lineLen <= args.Width {
 		// print the line with padding, the most common case
-		fmt.Printf(
-			"%s %s%s%s %s\n",
-			boxChars.VerticalEdge, // left-hand side of the bubble
-			line, resetColourANSI, // the text
+		fmt.Fprintf(
+			args.output(),
+			"%s %s%s%s%s %s\n",
+			edge,                                   // left-hand side of the bubble
+			args.TextColour, line, resetColourANSI, // the text
 			strings.Repeat(" ", args.Width-lineLen), // padding
-			boxChars.VerticalEdge,                   // right-hand side of the bubble
+			edge,                                    // right-hand side of the bubble
 		)
 	} else if lineLen > args.Width {
 		// print the line without padding or right-hand side of the bubble if the line is too long
-		fmt.Printf(
-			"%s %s%s\n",
-			boxChars.VerticalEdge, // left-hand side of the bubble
-			line, resetColourANSI, // the text
+		fmt.Fprintf(
+			args.output(),
+			"%s %s%s%s\n",
+			edge,                                   // left-hand side of the bubble
+			args.TextColour, line, resetColourANSI, // the text
 		)
 	}
 }
@@ -361,11 +416,132 @@ func ReverseANSIString(lines [][]ANSILineToken) [][]ANSILineToken {
 	return linesRev
 }
 
-// Prints a pokemon with its name & category information.
-func printPokemon(args Args, index int, names []string, categoryKeys []string, GOBCowData embed.FS) {
-	d, _ := GOBCowData.ReadFile(pokedex.EntryFpath("build/assets/cows", index))
-	timer.DebugTimer.Mark("read sprite file")
+// JoinANSIStrings lays out sprites side by side, from left to right, with a separator (e.g. an arrow) between each of them.
+// The sprites are aligned along the bottom, and each separator is drawn halfway up the shorter sprite next to it.
+// Every sprite is padded to its widest line, and colours are reset between them so that they don't run into each other
+func JoinANSIStrings(sprites [][][]ANSILineToken, separator string) [][]ANSILineToken {
+	height := 0
+	widths := make([]int, len(sprites))
+	for i, lines := range sprites {
+		height = max(height, len(lines))
+		for _, tokens := range lines {
+			widths[i] = max(widths[i], tokensWidth(tokens))
+		}
+	}
+	reset := func(text string) ANSILineToken {
+		return ANSILineToken{FG: resetColourANSI, BG: "", T: text}
+	}
 
+	joined := make([][]ANSILineToken, height)
+	for y := range joined {
+		tokens := make([]ANSILineToken, 0)
+		for i, lines := range sprites {
+			if i > 0 {
+				row := height - (min(len(lines), len(sprites[i-1]))+1)/2
+				if y == row {
+					tokens = append(tokens, reset(separator))
+				} else {
+					tokens = append(tokens, reset(strings.Repeat(" ", UnicodeStringLength(separator))))
+				}
+			}
+			width := 0
+			if offset := height - len(lines); y >= offset {
+				tokens = append(tokens, lines[y-offset]...)
+				width = tokensWidth(lines[y-offset])
+			}
+			if i < len(sprites)-1 {
+				tokens = append(tokens, reset(strings.Repeat(" ", widths[i]-width)))
+			}
+		}
+		joined[y] = tokens
+	}
+	return joined
+}
+
+// tokensWidth returns the width of a line of tokens, in terminal columns
+func tokensWidth(tokens []ANSILineToken) int {
+	width := 0
+	for _, token := range tokens {
+		width += UnicodeStringLength(token.T)
+	}
+	return width
+}
+
+const (
+	FlipHorizontal string = "h"  // mirror the pokemon left-to-right, so that it faces right
+	FlipVertical   string = "v"  // mirror the pokemon top-to-bottom, so that it's upside down
+	FlipBoth       string = "hv" // flip the pokemon both ways, i.e. rotate it by 180°
+)
+
+var (
+	// half & quadrant block glyphs, and the glyphs they become when the sprite is flipped top-to-bottom
+	verticalFlipGlyphs *strings.Replacer = strings.NewReplacer(
+		"▀", "▄", "▄", "▀", "▘", "▖", "▖", "▘", "▝", "▗", "▗", "▝",
+		"▚", "▞", "▞", "▚", "▛", "▙", "▙", "▛", "▜", "▟", "▟", "▜",
+	)
+	// half & quadrant block glyphs, and the glyphs they become when the sprite is flipped left-to-right
+	horizontalFlipGlyphs *strings.Replacer = strings.NewReplacer(
+		"▌", "▐", "▐", "▌", "▘", "▝", "▝", "▘", "▖", "▗", "▗", "▖",
+		"▚", "▞", "▞", "▚", "▛", "▜", "▜", "▛", "▙", "▟", "▟", "▙",
+	)
+)
+
+// ParseFlip parses a --flip value into a flip direction. An empty value flips horizontally (the original --flip),
+// and "true" & "false" are accepted from config files that set flip as a boolean
+func ParseFlip(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "true", FlipHorizontal:
+		return FlipHorizontal, nil
+	case "false":
+		return "", nil
+	case FlipVertical:
+		return FlipVertical, nil
+	case FlipBoth, "vh":
+		return FlipBoth, nil
+	}
+	return "", fmt.Errorf("invalid flip '%s', expected one of: %s, %s, %s", value, FlipHorizontal, FlipVertical, FlipBoth)
+}
+
+// FlipANSIString flips tokenised lines in a direction: horizontally, vertically or both
+func FlipANSIString(lines [][]ANSILineToken, direction string) [][]ANSILineToken {
+	if direction == FlipVertical || direction == FlipBoth {
+		lines = FlipVerticalANSIString(lines)
+	}
+	if direction == FlipHorizontal || direction == FlipBoth {
+		lines = ReverseANSIString(lines)
+		for _, tokens := range lines {
+			for i := range tokens {
+				tokens[i].T = horizontalFlipGlyphs.Replace(tokens[i].T)
+			}
+		}
+	}
+	return lines
+}
+
+// FlipVerticalANSIString flips tokenised lines top-to-bottom, by reversing the order of the lines and
+// swapping the half-block glyphs (e.g. ▀ <-> ▄).
+// Each line of a sprite is 2 rows of pixels: the glyph is drawn with the FG colour, and the rest of
+// the cell with the BG colour. Swapping the glyph moves the FG colour to the other half of the cell,
+// and so the BG colour with it, which keeps transparent (default BG) halves transparent.
+// A trailing empty line (after the final newline) is kept at the end
+func FlipVerticalANSIString(lines [][]ANSILineToken) [][]ANSILineToken {
+	n := len(lines)
+	if n > 0 && len(lines[n-1]) == 0 {
+		n--
+	}
+	flipped := make([][]ANSILineToken, 0, len(lines))
+	for idx := n - 1; idx >= 0; idx-- {
+		tokens := make([]ANSILineToken, len(lines[idx]))
+		for i, token := range lines[idx] {
+			tokens[i] = ANSILineToken{FG: token.FG, BG: token.BG, T: verticalFlipGlyphs.Replace(token.T)}
+		}
+		flipped = append(flipped, tokens)
+	}
+	return append(flipped, lines[n:]...)
+}
+
+// infoBox returns the info box of a pokemon, with its names & category information
+func infoBox(args Args, names []string, categoryKeys []string) string {
 	width := nameLength(names)
 	namesFmt := make([]string, 0)
 	for _, name := range names {
@@ -398,35 +574,84 @@ func printPokemon(args Args, index int, names []string, categoryKeys []string, G
 	}
 
 	if args.DrawInfoBorder {
-		topBorder := fmt.Sprintf(
-			"%s%s%s",
-			args.BoxChars.TopLeftCorner, strings.Repeat(args.BoxChars.HorizontalEdge, width-2), args.BoxChars.TopRightCorner,
+		topBorder := paint(
+			args.BubbleColour,
+			args.BoxChars.TopLeftCorner+strings.Repeat(args.BoxChars.HorizontalEdge, width-2)+args.BoxChars.TopRightCorner,
 		)
-		bottomBorder := fmt.Sprintf(
-			"%s%s%s",
-			args.BoxChars.BottomLeftCorner, strings.Repeat(args.BoxChars.HorizontalEdge, width-2), args.BoxChars.BottomRightCorner,
+		bottomBorder := paint(
+			args.BubbleColour,
+			args.BoxChars.BottomLeftCorner+strings.Repeat(args.BoxChars.HorizontalEdge, width-2)+args.BoxChars.BottomRightCorner,
 		)
+		edge := paint(args.BubbleColour, args.BoxChars.VerticalEdge)
 		infoLine = fmt.Sprintf(
 			"%s\n%s %s %s\n%s\n",
-			topBorder, args.BoxChars.VerticalEdge, infoLine, args.BoxChars.VerticalEdge, bottomBorder,
+			topBorder, edge, infoLine, edge, bottomBorder,
 		)
 	} else {
 		infoLine = fmt.Sprintf("%s\n", infoLine)
 	}
-	timer.DebugTimer.Mark("generate string")
-	if args.FlipPokemon {
-		dec := pokedex.Decompress(d)
-		timer.DebugTimer.Mark("gunzip string")
+	return infoLine
+}
 
-		flipped := BuildANSIString(ReverseANSIString(TokeniseANSIString(string(dec))), 4)
-		timer.DebugTimer.Mark("reverse string")
+// spriteLines tokenises a sprite, and resizes, filters & flips it. It also returns the padding that the
+// sprite needs on the left
+func spriteLines(args Args, dec []byte) ([][]ANSILineToken, int) {
+	lines := TokeniseANSIString(string(dec))
+	if n := len(lines); n > 0 && len(lines[n-1]) == 0 {
+		// remove the empty line after the final newline, as BuildANSIString adds a newline to every line
+		lines = lines[:n-1]
+	}
+	if scale := ScaleForHeight(lines, cmp.Or(args.Scale, 1), args.MaxHeight); scale != 1 {
+		lines = ScaleANSIString(lines, scale)
+		timer.DebugTimer.Mark("scale string")
+	}
+	if args.Filter != "" {
+		lines = FilterANSIString(lines, args.Filter)
+		timer.DebugTimer.Mark("filter string")
+	}
+	padding := 0
+	if args.Flip == FlipHorizontal || args.Flip == FlipBoth {
+		// horizontal flips need padding, as they move the sprite to the right
+		padding = 4
+	}
+	if args.Flip != "" {
+		lines = FlipANSIString(lines, args.Flip)
+		timer.DebugTimer.Mark("flip string")
+	}
+	return lines, padding
+}
 
-		fmt.Printf("%s%s", flipped, infoLine)
+// Prints a pokemon with its name & category information.
+func printPokemon(args Args, dec []byte, names []string, categoryKeys []string) {
+	info := infoBox(args, names, categoryKeys)
+	timer.DebugTimer.Mark("generate string")
+	if args.Flip != "" || cmp.Or(args.Scale, 1) != 1 || args.MaxHeight > 0 || args.Filter != "" {
+		lines, padding := spriteLines(args, dec)
+		fmt.Fprintf(args.output(), "%s%s", BuildANSIString(lines, padding), info)
 	} else {
-		dec := pokedex.Decompress(d)
-		timer.DebugTimer.Mark("gunzip string")
+		fmt.Fprintf(args.output(), "%s%s", dec, info)
+	}
+	timer.DebugTimer.Mark("print to terminal")
+}
+
+// PrintSprites prints the message inside a speech bubble, followed by sprites side by side (e.g. the
+// evolution line of a pokemon) with an arrow between each of them, and their names & category information
+func PrintSprites(args Args, sprites [][]byte, names []string, categories []string) {
+	tokenised := make([][][]ANSILineToken, len(sprites))
+	padding := 0
+	for i, sprite := range sprites {
+		tokenised[i], padding = spriteLines(args, sprite)
+	}
+	lines := JoinANSIStrings(tokenised, " "+args.BoxChars.RightArrow+" ")
+	timer.DebugTimer.Mark("join sprites")
 
-		fmt.Printf("%s%s", dec, infoLine)
+	if args.BubbleColour == AutoColour {
+		args.BubbleColour = DominantColour(lines)
+		timer.DebugTimer.Mark("find dominant colour")
+	}
+	if args.Message != nil {
+		printSpeechBubble(args.BoxChars, args.Message, args)
 	}
+	fmt.Fprintf(args.output(), "%s%s", BuildANSIString(lines, padding), infoBox(args, names, categories))
 	timer.DebugTimer.Mark("print to terminal")
 }
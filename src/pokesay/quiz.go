@@ -0,0 +1,144 @@
+package pokesay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// QuizState is the score of `pokesay quiz`, which is kept between games in a state file
+type QuizState struct {
+	Streak  int `json:"streak"`  // the number of correct guesses in a row
+	Best    int `json:"best"`    // the longest streak
+	Played  int `json:"played"`  // the number of games played
+	Correct int `json:"correct"` // the number of correct guesses
+}
+
+// QuizStateFpath returns the path of the quiz state file, $XDG_STATE_HOME/pokesay/quiz.json,
+// falling back to ~/.local/state/pokesay/quiz.json when $XDG_STATE_HOME isn't set
+func QuizStateFpath() string {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		stateDir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateDir, "pokesay", "quiz.json")
+}
+
+// ReadQuizState reads the quiz state file. If the file doesn't exist, an empty state is returned
+func ReadQuizState(fpath string) (QuizState, error) {
+	var state QuizState
+	data, err := os.ReadFile(fpath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, fmt.Errorf("could not read quiz state: %w", err)
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("could not read quiz state %s: %w", fpath, err)
+	}
+	return state, nil
+}
+
+// WriteQuizState writes the quiz state file, creating its directory if needed
+func WriteQuizState(fpath string, state QuizState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
+		return fmt.Errorf("could not write quiz state: %w", err)
+	}
+	if err := os.WriteFile(fpath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("could not write quiz state: %w", err)
+	}
+	return nil
+}
+
+// Record adds the result of a game to the score
+func (s *QuizState) Record(correct bool) {
+	s.Played++
+	if correct {
+		s.Correct++
+		s.Streak++
+		s.Best = max(s.Best, s.Streak)
+	} else {
+		s.Streak = 0
+	}
+}
+
+// normaliseName makes a name comparable to a guess, by lowercasing it and removing everything
+// but letters & numbers, e.g. "Mr. Mime" -> "mrmime" and "Flabébé" -> "flabebe"
+func normaliseName(name string) string {
+	var normalised strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r == 'é':
+			normalised.WriteRune('e')
+		case r == '♀':
+			normalised.WriteString("f")
+		case r == '♂':
+			normalised.WriteString("m")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			normalised.WriteRune(r)
+		}
+	}
+	return normalised.String()
+}
+
+// levenshtein returns the number of single character edits needed to change one string into another
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+// MatchGuess finds the name that a guess is closest to, so that small typos still match
+// (about 1 edit for every 4 characters). An exact match is always preferred, so guessing
+// the name of a similar pokemon (e.g. charmeleon for charmander) matches that pokemon instead.
+// If no name is close enough, "" is returned
+func MatchGuess(guess string, names []string) string {
+	guess = normaliseName(guess)
+	if guess == "" {
+		return ""
+	}
+	match, distance := "", -1
+	for _, name := range names {
+		d := levenshtein(guess, normaliseName(name))
+		if distance == -1 || d < distance {
+			match, distance = name, d
+		}
+	}
+	if distance > len([]rune(guess))/4 {
+		return ""
+	}
+	return match
+}
+
+// IsSameName returns true if two names are the same, ignoring case, spaces & punctuation
+func IsSameName(a string, b string) bool {
+	return normaliseName(a) == normaliseName(b)
+}
@@ -0,0 +1,141 @@
+package pokesay
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MaxScale is the largest factor that a pokemon can be resized by
+const MaxScale float64 = 8
+
+// a pixel of a sprite is the colour parameters of its ANSI escape code, e.g. "5;16" for
+// "\x1b[38;5;16m" (as a foreground) & "\x1b[48;5;16m" (as a background), or "" if it's transparent
+type pixel string
+
+// ParseScale parses a --scale value, e.g. "0.5" or "2"
+func ParseScale(value string) (float64, error) {
+	scale, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || !(scale > 0 && scale <= MaxScale) {
+		return 0, fmt.Errorf("invalid scale '%s', expected a number greater than 0 and up to %g, e.g. 0.5 or 2", value, MaxScale)
+	}
+	return scale, nil
+}
+
+// ScaleForHeight returns the largest scale (up to the requested scale) that fits the sprite in
+// maxHeight lines. A maxHeight of 0 means there is no limit
+func ScaleForHeight(lines [][]ANSILineToken, scale float64, maxHeight int) float64 {
+	height := len(decodePixels(lines))
+	if maxHeight <= 0 || height == 0 {
+		return scale
+	}
+	return math.Min(scale, float64(maxHeight*2)/float64(height))
+}
+
+// ScaleANSIString resizes a sprite by a factor, e.g. 0.5 to halve it or 2 to double it.
+// Each line of a sprite is 2 rows of pixels drawn with half-blocks (▀ & ▄), so the lines are decoded into
+// pixels, resampled (nearest-neighbour) and then encoded back into half-blocks.
+// Any other glyphs are treated as a solid block of their foreground colour
+func ScaleANSIString(lines [][]ANSILineToken, scale float64) [][]ANSILineToken {
+	pixels := decodePixels(lines)
+	if len(pixels) == 0 || scale == 1 {
+		return lines
+	}
+	height := max(1, int(math.Round(float64(len(pixels))*scale)))
+	width := max(1, int(math.Round(float64(len(pixels[0]))*scale)))
+
+	scaled := make([][]pixel, height)
+	for y := range scaled {
+		row := pixels[min(len(pixels)-1, int(float64(y)/scale))]
+		scaled[y] = make([]pixel, width)
+		for x := range scaled[y] {
+			scaled[y][x] = row[min(len(row)-1, int(float64(x)/scale))]
+		}
+	}
+	return encodePixels(scaled)
+}
+
+// parsePixel returns the pixel of a foreground or background ANSI escape code
+func parsePixel(code string, prefix string) pixel {
+	if colour, ok := strings.CutPrefix(code, "\x1b["+prefix+";"); ok {
+		return pixel(strings.TrimSuffix(colour, "m"))
+	}
+	return ""
+}
+
+// decodePixels converts the lines of a sprite into a grid of pixels, 2 rows per line.
+// Every row has the same width, and a trailing empty line (after the final newline) is ignored
+func decodePixels(lines [][]ANSILineToken) [][]pixel {
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	pixels := make([][]pixel, 0, len(lines)*2)
+	width := 0
+	for _, tokens := range lines {
+		top, bottom := make([]pixel, 0), make([]pixel, 0)
+		for _, token := range tokens {
+			fg, bg := parsePixel(token.FG, "38"), parsePixel(token.BG, "48")
+			for _, ch := range token.T {
+				switch ch {
+				case ' ':
+					top, bottom = append(top, bg), append(bottom, bg)
+				case '▀':
+					top, bottom = append(top, fg), append(bottom, bg)
+				case '▄':
+					top, bottom = append(top, bg), append(bottom, fg)
+				default:
+					top, bottom = append(top, fg), append(bottom, fg)
+				}
+			}
+		}
+		width = max(width, len(top))
+		pixels = append(pixels, top, bottom)
+	}
+	for i, row := range pixels {
+		pixels[i] = append(row, make([]pixel, width-len(row))...)
+	}
+	return pixels
+}
+
+// encodePixels converts a grid of pixels into the lines of a sprite, drawn with half-blocks.
+// Cells with the same colours are joined into one token, and trailing transparent cells & lines are removed
+func encodePixels(pixels [][]pixel) [][]ANSILineToken {
+	lines := make([][]ANSILineToken, 0, (len(pixels)+1)/2)
+	for y := 0; y < len(pixels); y += 2 {
+		bottom := make([]pixel, len(pixels[y]))
+		if y+1 < len(pixels) {
+			bottom = pixels[y+1]
+		}
+		width := len(pixels[y])
+		for width > 0 && pixels[y][width-1] == "" && bottom[width-1] == "" {
+			width--
+		}
+		tokens := make([]ANSILineToken, 0)
+		fg := "\x1b[39m"
+		for x, top := range pixels[y][:width] {
+			// transparent cells are a space with no background, in the foreground colour of the previous cell
+			token := ANSILineToken{FG: fg, BG: "\x1b[49m", T: " "}
+			switch {
+			case top == "" && bottom[x] == "":
+			case top == "":
+				token.FG, token.T = "\x1b[38;"+string(bottom[x])+"m", "▄"
+			case bottom[x] == "":
+				token.FG, token.T = "\x1b[38;"+string(top)+"m", "▀"
+			default:
+				token.FG, token.BG, token.T = "\x1b[38;"+string(bottom[x])+"m", "\x1b[48;"+string(top)+"m", "▄"
+			}
+			fg = token.FG
+			if n := len(tokens); n > 0 && tokens[n-1].FG == token.FG && tokens[n-1].BG == token.BG {
+				tokens[n-1].T += token.T
+			} else {
+				tokens = append(tokens, token)
+			}
+		}
+		lines = append(lines, tokens)
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
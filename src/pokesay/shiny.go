@@ -0,0 +1,69 @@
+package pokesay
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	CategoryShiny   string = "shiny"
+	CategoryRegular string = "regular"
+	// ShinyMarker is printed after the name of a shiny pokemon in the info box, when it was chosen with --shiny-odds
+	ShinyMarker string = "✨"
+)
+
+// ParseShinyOdds parses a --shiny-odds value, as a fraction (e.g. 1/4096) or a probability (e.g. 0.5)
+func ParseShinyOdds(value string) (float64, error) {
+	odds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if numerator, denominator, ok := strings.Cut(value, "/"); ok {
+		n, nErr := strconv.ParseFloat(strings.TrimSpace(numerator), 64)
+		d, dErr := strconv.ParseFloat(strings.TrimSpace(denominator), 64)
+		if err = cmp.Or(nErr, dErr); err == nil && d != 0 {
+			odds = n / d
+		}
+	}
+	if err != nil || !(odds > 0 && odds <= 1) {
+		return 0, fmt.Errorf("invalid shiny odds '%s', expected a fraction or probability greater than 0 and up to 1, e.g. 1/4096 or 0.5", value)
+	}
+	return odds, nil
+}
+
+// IsShiny returns true if a sprite is in the shiny category
+func IsShiny(choice Choice) bool {
+	return slices.Contains(choice.Entry.Categories, CategoryShiny)
+}
+
+// RollShiny chooses whether a pokemon is shiny with some odds (e.g. 1/4096), and returns its shiny or regular sprite
+func (p *Pokedex) RollShiny(choice Choice, odds float64) Choice {
+	return p.WithShiny(choice, RandomFloat() < odds)
+}
+
+// WithShiny returns the shiny (or regular) sprite of a pokemon that has the same other categories as a chosen sprite,
+// e.g. pikachu/gen8/regular -> pikachu/gen8/shiny. If the pokemon has no such sprite, then a random shiny (or regular)
+// sprite is chosen instead, and if it has none of those either, the chosen sprite is kept
+func (p *Pokedex) WithShiny(choice Choice, shiny bool) Choice {
+	if IsShiny(choice) == shiny {
+		return choice
+	}
+	swap := map[string]string{CategoryShiny: CategoryRegular, CategoryRegular: CategoryShiny}
+	want := make([]string, 0, len(choice.Entry.Categories))
+	for _, category := range choice.Entry.Categories {
+		want = append(want, cmp.Or(swap[category], category))
+	}
+	candidates := make([]Choice, 0)
+	for _, sprite := range p.Sprites(choice) {
+		if slices.Equal(sprite.Entry.Categories, want) {
+			return sprite
+		}
+		if IsShiny(sprite) == shiny {
+			candidates = append(candidates, sprite)
+		}
+	}
+	if len(candidates) == 0 {
+		return choice
+	}
+	return candidates[RandomInt(len(candidates))]
+}
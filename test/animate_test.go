@@ -0,0 +1,41 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseAnimate(test *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		chosen   bool
+		expected string
+	}{
+		{"", true, pokesay.AnimateVariants},
+		{"", false, pokesay.AnimateRandom},
+		{"variants", false, pokesay.AnimateVariants},
+		{" Random ", true, pokesay.AnimateRandom},
+	} {
+		result, err := pokesay.ParseAnimate(tc.value, tc.chosen)
+		Assert(nil, err, test)
+		Assert(tc.expected, result, test)
+	}
+
+	_, err := pokesay.ParseAnimate("spin", false)
+	Assert("invalid animation 'spin', expected one of: variants, random", err.Error(), test)
+}
+
+func TestRedrawFrame(test *testing.T) {
+	frame, lines := pokesay.RedrawFrame("a\nb\n", 0, false)
+	Assert("a\nb\n", frame, test)
+	Assert(2, lines, test)
+
+	frame, lines = pokesay.RedrawFrame("c\nd\ne\n", 2, false)
+	Assert("\x1b[2A\r\x1b[Jc\nd\ne\n", frame, test)
+	Assert(3, lines, test)
+
+	frame, lines = pokesay.RedrawFrame("f\ng\n", 3, true)
+	Assert("\x1b[3A\r\x1b[Jf\r\ng\r\n", frame, test)
+	Assert(2, lines, test)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestBorderStylesAreSingleColumn(test *testing.T) {
+	for _, name := range pokesay.BorderStyleNames() {
+		boxChars, err := pokesay.LoadBorderStyle(name, nil)
+		Assert(nil, err, test)
+		Assert(nil, boxChars.Validate(), test)
+	}
+}
+
+func TestLoadBorderStyle(test *testing.T) {
+	boxChars, err := pokesay.LoadBorderStyle("rounded", nil)
+	Assert(nil, err, test)
+	Assert(pokesay.UnicodeBoxChars, boxChars, test)
+
+	_, err = pokesay.LoadBorderStyle("wavy", nil)
+	Assert("unknown border style 'wavy', expected one of: ascii, block, dashed, double, heavy, none, rounded, unicode", err.Error(), test)
+}
+
+func TestLoadUserBorderStyle(test *testing.T) {
+	sections := map[string]map[string]string{
+		"border-styles.stars":   {"base": "ascii", "horizontal-edge": "*", "top-left-corner": "+"},
+		"border-styles.double":  {"vertical-edge": "!"},
+		"border-styles.wide":    {"vertical-edge": "ＷＷ"},
+		"border-styles.typo":    {"top-left-coner": "+"},
+		"border-styles.nobase":  {"base": "wavy"},
+		"border-styles.default": {},
+	}
+
+	expected := *pokesay.AsciiBoxChars
+	expected.HorizontalEdge, expected.TopLeftCorner = "*", "+"
+	boxChars, err := pokesay.LoadBorderStyle("stars", sections)
+	Assert(nil, err, test)
+	Assert(expected, *boxChars, test)
+
+	// user-defined styles override the built-in styles
+	boxChars, err = pokesay.LoadBorderStyle("double", sections)
+	Assert(nil, err, test)
+	Assert("!", boxChars.VerticalEdge, test)
+	Assert("─", boxChars.HorizontalEdge, test)
+
+	boxChars, err = pokesay.LoadBorderStyle("default", sections)
+	Assert(nil, err, test)
+	Assert(*pokesay.UnicodeBoxChars, *boxChars, test)
+
+	for name, expected := range map[string]string{
+		"wide":   "invalid border style 'wide': vertical-edge 'ＷＷ' must be a single column wide",
+		"typo":   "invalid border style 'typo': unknown glyph 'top-left-coner'",
+		"nobase": "unknown base 'wavy' for border style 'nobase'",
+	} {
+		_, err = pokesay.LoadBorderStyle(name, sections)
+		Assert(expected, err.Error(), test)
+	}
+}
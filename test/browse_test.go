@@ -0,0 +1,171 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokedex"
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func browseChoice(idx string, name string, id string, categories ...string) pokesay.Choice {
+	return pokesay.Choice{
+		ID:       id,
+		Metadata: pokedex.PokemonMetadata{Idx: idx, Name: name},
+		Entry:    pokedex.PokemonEntryMapping{Categories: categories, ID: id},
+	}
+}
+
+func newTestBrowser() *pokesay.Browser {
+	return pokesay.NewBrowser([]pokesay.Choice{
+		browseChoice("0", "Pikachu", "pikachu/gen8/regular", "small", "gen8", "regular", "type:electric"),
+		browseChoice("0", "Pikachu", "pikachu/gen8/shiny", "small", "gen8", "shiny", "type:electric"),
+		browseChoice("0", "Pikachu", "pikachu/gen7x/regular", "small", "gen7x", "regular", "type:electric"),
+		browseChoice("1", "Onix", "onix/gen7x/regular", "big", "gen7x", "regular", "type:rock"),
+		browseChoice("2", "Eevee", "eevee/gen8/regular", "small", "gen8", "regular", "type:normal"),
+	})
+}
+
+func browseNames(items []pokesay.BrowseItem) []string {
+	names := make([]string, 0)
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func TestNewBrowser(test *testing.T) {
+	browser := newTestBrowser()
+
+	Assert([]string{"Eevee", "Onix", "Pikachu"}, browseNames(browser.Visible()), test)
+	Assert(3, len(browser.Items[2].Sprites), test)
+	Assert([]string{"big", "gen7x", "gen8", "regular", "shiny", "small"}, browser.Categories, test)
+}
+
+func TestBrowserSearch(test *testing.T) {
+	browser := newTestBrowser()
+	for _, key := range []string{"/", "I", "x", "x", "backspace"} {
+		Assert(pokesay.BrowseNone, browser.HandleKey(key), test)
+	}
+	Assert(true, browser.Searching, test)
+	Assert("Ix", browser.Query, test)
+	Assert([]string{"Onix"}, browseNames(browser.Visible()), test)
+
+	// keys are commands again after the search
+	browser.HandleKey("enter")
+	Assert(pokesay.BrowseQuit, browser.HandleKey("q"), test)
+
+	// esc clears the search
+	browser.HandleKey("/")
+	browser.HandleKey("esc")
+	Assert("", browser.Query, test)
+	Assert(3, len(browser.Visible()), test)
+}
+
+func TestBrowserMove(test *testing.T) {
+	browser := newTestBrowser()
+
+	browser.HandleKey("end")
+	choice, _ := browser.Selected()
+	Assert("pikachu/gen8/regular", choice.ID, test)
+
+	browser.HandleKey("right")
+	browser.HandleKey("right")
+	choice, _ = browser.Selected()
+	Assert("pikachu/gen7x/regular", choice.ID, test)
+	browser.HandleKey("right")
+	choice, _ = browser.Selected()
+	Assert("pikachu/gen8/regular", choice.ID, test)
+	browser.HandleKey("left")
+	choice, _ = browser.Selected()
+	Assert("pikachu/gen7x/regular", choice.ID, test)
+
+	// moving to another pokemon selects its first sprite
+	browser.HandleKey("up")
+	choice, _ = browser.Selected()
+	Assert("onix/gen7x/regular", choice.ID, test)
+	browser.HandleKey("k")
+	browser.HandleKey("k")
+	choice, _ = browser.Selected()
+	Assert("eevee/gen8/regular", choice.ID, test)
+}
+
+func TestBrowserShinyAndFlip(test *testing.T) {
+	browser := newTestBrowser()
+	browser.HandleKey("G")
+
+	browser.HandleKey("s")
+	choice, _ := browser.Selected()
+	Assert("pikachu/gen8/shiny", choice.ID, test)
+	browser.HandleKey("s")
+	choice, _ = browser.Selected()
+	Assert("pikachu/gen8/regular", choice.ID, test)
+
+	browser.HandleKey("f")
+	Assert("pikachu/gen8/regular", choice.ID, test)
+	Assert("pokesay -i pikachu/gen8/regular -F", browser.CommandLine(), test)
+	Assert(pokesay.BrowseCopyCommand, browser.HandleKey("c"), test)
+	Assert(pokesay.BrowseCopyID, browser.HandleKey("y"), test)
+
+	// there is no shiny onix
+	browser.HandleKey("up")
+	browser.HandleKey("s")
+	Assert("Onix has no big/gen7x/shiny/type:rock sprite that matches the categories", browser.Status, test)
+}
+
+func TestBrowserCategories(test *testing.T) {
+	browser := newTestBrowser()
+
+	// toggle gen7x
+	browser.HandleKey("tab")
+	browser.HandleKey(" ")
+	Assert([]string{"Onix", "Pikachu"}, browseNames(browser.Visible()), test)
+
+	browser.HandleKey("G")
+	choice, _ := browser.Selected()
+	Assert("pikachu/gen7x/regular", choice.ID, test)
+
+	// toggle shiny as well, which no pokemon matches
+	browser.HandleKey("tab")
+	browser.HandleKey("tab")
+	browser.HandleKey("tab")
+	browser.HandleKey(" ")
+	_, ok := browser.Selected()
+	Assert(false, ok, test)
+	Assert("", browser.CommandLine(), test)
+
+	browser.HandleKey("shift-tab")
+	browser.HandleKey("shift-tab")
+	browser.HandleKey("shift-tab")
+	browser.HandleKey(" ")
+	Assert([]string{"Pikachu"}, browseNames(browser.Visible()), test)
+	Assert(map[string]bool{"gen7x": false, "shiny": true}, browser.Enabled, test)
+}
+
+func TestBrowserRender(test *testing.T) {
+	browser := newTestBrowser()
+	browser.HandleKey("down")
+
+	sprite := func(choice pokesay.Choice) []byte {
+		return []byte("\x1b[38;5;160m▄▄▄▄\n▀▀▀▀\n")
+	}
+	screen := browser.Render(80, 24, sprite)
+
+	for _, expected := range []string{"(3 of 3 pokemon)", "\x1b[7m[ ] big\x1b[27m", "\x1b[7m Onix", "onix/gen7x/regular", "sprite 1 of 1", "▄▄▄▄", "q quit"} {
+		if !strings.Contains(screen, expected) {
+			test.Errorf("expected the screen to contain %q:\n%q", expected, screen)
+		}
+	}
+}
+
+func TestParseKeys(test *testing.T) {
+	Assert(
+		[]string{"up", "down", "right", "left", "up", "pgdown", "shift-tab", "esc", "a", "é", "enter", "tab", "backspace", "ctrl-c"},
+		pokesay.ParseKeys([]byte("\x1b[A\x1b[B\x1b[C\x1b[D\x1bOA\x1b[6~\x1b[Z\x1baé\r\t\x7f\x03\x01\x1b[15~")),
+		test,
+	)
+}
+
+func TestClipboardSequence(test *testing.T) {
+	Assert("\x1b]52;c;cGlrYWNodS9nZW44L3NoaW55\x07", pokesay.ClipboardSequence("pikachu/gen8/shiny"), test)
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseColour(test *testing.T) {
+	for colour, expected := range map[string]string{
+		"":        "",
+		"auto":    pokesay.AutoColour,
+		"red":     "\x1b[31m",
+		"Cyan":    "\x1b[36m",
+		"208":     "\x1b[38;5;208m",
+		"#ff8800": "\x1b[38;2;255;136;0m",
+		"#F80":    "\x1b[38;2;255;136;0m",
+	} {
+		result, err := pokesay.ParseColour(colour)
+		Assert(nil, err, test)
+		Assert(expected, result, test)
+	}
+	for _, colour := range []string{"pink", "256", "-1", "#ff880", "ff8800"} {
+		_, err := pokesay.ParseColour(colour)
+		Assert(true, err != nil, test)
+	}
+}
+
+func TestDominantColour(test *testing.T) {
+	// an orange pokemon with a black outline
+	sprite := "\x1b[38;5;16m▄▄▄▄\x1b[48;5;16m\x1b[38;5;208m▄▄\x1b[48;5;208m  \x1b[38;5;16m▀\x1b[49m \x1b[0m\n" +
+		"\x1b[38;5;16m▀▀▀▀\x1b[38;5;33m▀\x1b[0m"
+
+	Assert("\x1b[38;5;208m", pokesay.DominantColour(pokesay.TokeniseANSIString(sprite)), test)
+}
+
+func TestDominantColourDark(test *testing.T) {
+	// dark colours are only used when there are no other colours
+	sprite := "\x1b[38;5;16m▄▄▄▄\x1b[38;5;232m▄▄\x1b[0m"
+	Assert("\x1b[38;5;16m", pokesay.DominantColour(pokesay.TokeniseANSIString(sprite)), test)
+
+	Assert("", pokesay.DominantColour(pokesay.TokeniseANSIString("no colours here")), test)
+}
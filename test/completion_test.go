@@ -0,0 +1,103 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+var (
+	completionFlags []pokesay.FlagInfo = []pokesay.FlagInfo{
+		{Short: "h", Long: "help", Help: "display this help message"},
+		{Short: "n", Long: "name", Help: "choose a pokemon's name", Value: "value", Complete: pokesay.Completion{Command: "list names"}},
+		{Long: "format", Help: "the output [format]", Value: "format", Complete: pokesay.Completion{Words: []string{"json", "csv"}}},
+		{Long: "pack", Help: "load a pack", Value: "dir", Repeatable: true, Complete: pokesay.Completion{Dirs: true}},
+	}
+	completionCommands []pokesay.CommandInfo = []pokesay.CommandInfo{
+		{Name: "random", Help: "print a random pokemon"},
+		{Name: "list", Help: "list things", Complete: pokesay.Completion{Words: []string{"names", "ids"}}},
+	}
+)
+
+func writeCompletion(shell string, test *testing.T) string {
+	var buf bytes.Buffer
+	Assert(nil, pokesay.WriteCompletion(&buf, shell, "pokesay", completionFlags, completionCommands), test)
+	return buf.String()
+}
+
+func TestBashCompletion(test *testing.T) {
+	script := writeCompletion("bash", test)
+
+	for _, expected := range []string{
+		"        -n|--name)\n            COMPREPLY=( $(compgen -W \"$(pokesay list names 2>/dev/null)\" -- \"${cur}\") )\n",
+		"        --pack)\n            COMPREPLY=( $(compgen -d -- \"${cur}\") )\n",
+		"compgen -W \"-h --help -n --name --format --pack\"",
+		"        \"\") COMPREPLY=( $(compgen -W \"random list\" -- \"${cur}\") ) ;;\n",
+		"        list) [[ \"${prev}\" == list ]] && COMPREPLY=( $(compgen -W \"names ids\" -- \"${cur}\") ) ;;\n",
+		"complete -F _pokesay pokesay\n",
+	} {
+		Assert(true, strings.Contains(script, expected), test)
+	}
+	// flags without a value don't complete one
+	Assert(false, strings.Contains(script, "-h|--help)"), test)
+}
+
+func TestZshCompletion(test *testing.T) {
+	script := writeCompletion("zsh", test)
+
+	for _, expected := range []string{
+		"#compdef pokesay\n",
+		"    '(-h --help)'{-h,--help}'[display this help message]' \\\n",
+		"    '(-n --name)'{-n+,--name=}'[choose a pokemon'\\''s name]:value:_pokesay_values list names' \\\n",
+		"    --format='[the output \\[format\\]]:format:(json csv)' \\\n",
+		"    '*'--pack='[load a pack]:dir:_files -/' \\\n",
+		"    'random:print a random pokemon'\n",
+		"        list) compadd names ids ;;\n",
+	} {
+		Assert(true, strings.Contains(script, expected), test)
+	}
+}
+
+func TestFishCompletion(test *testing.T) {
+	script := writeCompletion("fish", test)
+
+	for _, expected := range []string{
+		"complete -c pokesay -s h -l help -d 'display this help message'\n",
+		"complete -c pokesay -s n -l name -d 'choose a pokemon\\'s name' -r -a '(pokesay list names 2>/dev/null)'\n",
+		"complete -c pokesay -l pack -d 'load a pack' -r -a '(__fish_complete_directories)'\n",
+		"complete -c pokesay -n '__fish_use_subcommand' -a random -d 'print a random pokemon'\n",
+		"complete -c pokesay -n '__fish_seen_subcommand_from list; and not __fish_seen_subcommand_from names ids' -a 'names ids'\n",
+	} {
+		Assert(true, strings.Contains(script, expected), test)
+	}
+}
+
+func TestCompletionInvalidShell(test *testing.T) {
+	var buf bytes.Buffer
+	err := pokesay.WriteCompletion(&buf, "powershell", "pokesay", completionFlags, completionCommands)
+	Assert("invalid shell 'powershell', expected one of: bash, zsh, fish", err.Error(), test)
+}
+
+func TestMatchUsage(test *testing.T) {
+	for _, tc := range []struct {
+		usage    string
+		params   []string
+		expected bool
+	}{
+		{"<name|id>", []string{"pikachu"}, true},
+		{"<name|id>", []string{}, false},
+		// a message that starts with the name of a command
+		{"<name|id>", []string{"me", "the", "money"}, false},
+		{"names|categories|ids", []string{"ids"}, true},
+		{"names|categories|ids", []string{"of", "things"}, false},
+		{"names|categories|ids", []string{"everything"}, false},
+		{"", []string{}, true},
+		{"", []string{"thought", "here"}, false},
+		{"[message ...]", []string{}, true},
+		{"[message ...]", []string{"thought", "here"}, true},
+	} {
+		Assert(tc.expected, pokesay.MatchUsage(tc.usage, tc.params), test)
+	}
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseConfig(test *testing.T) {
+	config, err := pokesay.ParseConfig([]byte(`
+# defaults
+width = 60
+scale = 0.5
+unicode-borders = true # nicer borders
+category = "gen8"
+name = 'pikachu'
+pack = ["/tmp/packs/a", "/tmp/packs/b#c",]
+
+[themes.mine]
+top = "~"
+`))
+	Assert(nil, err, test)
+
+	Assert(
+		map[string]string{
+			"width": "60", "scale": "0.5", "unicode-borders": "true", "category": "gen8", "name": "pikachu", "pack": "/tmp/packs/a,/tmp/packs/b#c",
+		},
+		config.Options,
+		test,
+	)
+	Assert(map[string]map[string]string{"themes.mine": {"top": "~"}}, config.Sections, test)
+}
+
+func TestParseConfigErrors(test *testing.T) {
+	for data, expected := range map[string]string{
+		"width":             "1: expected 'key = value', got 'width'",
+		"\n\nwidth =":       "3: missing value for 'width'",
+		"category = gen8":   "1: invalid value gen8 (strings must be quoted) for 'category'",
+		"category = \"gen8": "1: invalid string \"gen8 for 'category'",
+		"[themes":           "1: invalid section '[themes'",
+	} {
+		_, err := pokesay.ParseConfig([]byte(data))
+		Assert(expected, err.Error(), test)
+	}
+}
+
+func TestReadConfig(test *testing.T) {
+	fpath := filepath.Join(test.TempDir(), "config.toml")
+
+	config, err := pokesay.ReadConfig(fpath, true)
+	Assert(nil, err, test)
+	Assert(0, len(config.Options), test)
+
+	_, err = pokesay.ReadConfig(fpath, false)
+	Assert(true, err != nil, test)
+
+	os.WriteFile(fpath, []byte("width = x"), 0644)
+	_, err = pokesay.ReadConfig(fpath, true)
+	Assert(fpath+":1: invalid value x (strings must be quoted) for 'width'", err.Error(), test)
+}
+
+func TestConfigFpath(test *testing.T) {
+	test.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	Assert("/tmp/xdg/pokesay/config.toml", pokesay.ConfigFpath(), test)
+
+	test.Setenv("XDG_CONFIG_HOME", "")
+	test.Setenv("HOME", "/home/ash")
+	Assert("/home/ash/.config/pokesay/config.toml", pokesay.ConfigFpath(), test)
+}
+
+func TestEnvKey(test *testing.T) {
+	Assert("POKESAY_WIDTH", pokesay.EnvKey("width"), test)
+	Assert("POKESAY_JAPANESE_NAME", pokesay.EnvKey("japanese-name"), test)
+}
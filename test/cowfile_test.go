@@ -0,0 +1,80 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestExportCowfile(test *testing.T) {
+	sprite := []byte("\x1b[38;5;16m▄▄\x1b[0m $5 @home \\o/\n")
+
+	Assert(
+		"# Pikachu (pikachu/gen8/shiny), exported by pokesay\n"+
+			"$the_cow = <<\"EOC\";\n"+
+			"        $thoughts\n"+
+			"         $thoughts\n"+
+			"          $thoughts\n"+
+			"           $thoughts\n"+
+			"\\e[38;5;16m▄▄\\e[0m \\$5 \\@home \\\\o/\n"+
+			"EOC\n",
+		string(pokesay.ExportCowfile(sprite, "Pikachu (pikachu/gen8/shiny), exported by pokesay")),
+		test,
+	)
+}
+
+func TestCowfileRoundTrip(test *testing.T) {
+	sprite := []byte("\x1b[38;5;16m▄▄\x1b[0m $5 @home \\o/\n\x1b[48;5;208m  \x1b[49m\n")
+
+	parsed, err := pokesay.ParseCowfile(pokesay.ExportCowfile(sprite, "test"), "\\")
+	Assert(nil, err, test)
+	Assert("        \\\n         \\\n          \\\n           \\\n"+string(sprite), string(parsed), test)
+}
+
+func TestParseCowfile(test *testing.T) {
+	cow := "## the classic cow\n" +
+		"$the_cow = <<\"EOC\";\n" +
+		"        $thoughts   ^__^\n" +
+		"         $thoughts  (${eyes})\\\\_______\n" +
+		"            (__)\\\\       )\\\\/\\\\\n" +
+		"             $tongue ||----w |\n" +
+		"                ||     ||\n" +
+		"EOC\n"
+
+	parsed, err := pokesay.ParseCowfile([]byte(cow), "o")
+	Assert(nil, err, test)
+	Assert(
+		"        o   ^__^\n"+
+			"         o  (oo)\\_______\n"+
+			"            (__)\\       )\\/\\\n"+
+			"                ||----w |\n"+
+			"                ||     ||\n",
+		string(parsed),
+		test,
+	)
+}
+
+func TestParseCowfileEscapes(test *testing.T) {
+	cow := "$the_cow = <<EOC;\r\n" +
+		"\\e[31m\\033[32m\\x1b[33m\\x{1b}[34m\\N{U+2580}\\x{2584}\\\\ \\$thoughts \\@ $unknown\r\n" +
+		"EOC\r\n"
+
+	parsed, err := pokesay.ParseCowfile([]byte(cow), "o")
+	Assert(nil, err, test)
+	Assert("\x1b[31m\x1b[32m\x1b[33m\x1b[34m▀▄\\ $thoughts @ $unknown\n", string(parsed), test)
+}
+
+func TestParseCowfileSingleQuoted(test *testing.T) {
+	// single-quoted heredocs don't have escapes or variables
+	parsed, err := pokesay.ParseCowfile([]byte("$the_cow = <<'EOC';\n$thoughts \\\\o/\nEOC\n"), "o")
+	Assert(nil, err, test)
+	Assert("$thoughts \\\\o/\n", string(parsed), test)
+}
+
+func TestParseCowfileInvalid(test *testing.T) {
+	_, err := pokesay.ParseCowfile([]byte("just some text\n"), "o")
+	Assert("invalid cowfile, expected a $the_cow = <<EOC heredoc", err.Error(), test)
+
+	_, err = pokesay.ParseCowfile([]byte("$the_cow = <<EOC;\n^__^\n"), "o")
+	Assert("invalid cowfile, the $the_cow heredoc isn't terminated by EOC", err.Error(), test)
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseFilter(test *testing.T) {
+	for value, expected := range map[string]string{"": "", "grayscale": "grayscale", " Silhouette ": "silhouette"} {
+		result, err := pokesay.ParseFilter(value)
+		Assert(nil, err, test)
+		Assert(expected, result, test)
+	}
+	_, err := pokesay.ParseFilter("blur")
+	Assert("invalid filter 'blur', expected one of: grayscale, sepia, invert, silhouette, deuteranopia", err.Error(), test)
+}
+
+func TestFilterANSIString(test *testing.T) {
+	// red on a transparent background, white on black (as a 24-bit colour), and then a reset
+	sprite := [][]pokesay.ANSILineToken{
+		{
+			{FG: "\x1b[38;5;196m", BG: "\x1b[49m", T: "▄"},
+			{FG: "\x1b[38;2;255;255;255m", BG: "\x1b[48;5;16m", T: "▀"},
+			{FG: "\x1b[0m", BG: "", T: " "},
+		},
+	}
+
+	testCases := map[string][]pokesay.ANSILineToken{
+		"": sprite[0],
+		pokesay.FilterGrayscale: {
+			{FG: "\x1b[38;5;239m", BG: "\x1b[49m", T: "▄"},
+			{FG: "\x1b[38;5;231m", BG: "\x1b[48;5;16m", T: "▀"},
+			{FG: "\x1b[0m", BG: "", T: " "},
+		},
+		pokesay.FilterInvert: {
+			{FG: "\x1b[38;5;51m", BG: "\x1b[49m", T: "▄"},
+			{FG: "\x1b[38;5;16m", BG: "\x1b[48;5;231m", T: "▀"},
+			{FG: "\x1b[0m", BG: "", T: " "},
+		},
+		pokesay.FilterSilhouette: {
+			{FG: "\x1b[38;5;240m", BG: "\x1b[49m", T: "▄"},
+			{FG: "\x1b[38;5;240m", BG: "\x1b[48;5;240m", T: "▀"},
+			{FG: "\x1b[0m", BG: "", T: " "},
+		},
+	}
+	for filter, expected := range testCases {
+		test.Run(filter, func(t *testing.T) {
+			Assert([][]pokesay.ANSILineToken{expected}, pokesay.FilterANSIString(sprite, filter), t)
+		})
+	}
+}
+
+func TestFilterANSIStringDeuteranopia(test *testing.T) {
+	// red & green look alike with deuteranopia, so they are shifted apart, but gray is unchanged
+	sprite := pokesay.TokeniseANSIString("\x1b[38;5;160m▄\x1b[38;5;70m▄\x1b[38;5;244m▄")
+	filtered := pokesay.FilterANSIString(sprite, pokesay.FilterDeuteranopia)
+
+	Assert("\x1b[38;5;244m", filtered[0][2].FG, test)
+	Assert(true, filtered[0][0].FG != sprite[0][0].FG, test)
+	Assert(true, filtered[0][0].FG != filtered[0][1].FG, test)
+}
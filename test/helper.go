@@ -1,11 +1,16 @@
 package test
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 var (
@@ -63,3 +68,26 @@ func FlattenJSON(json string) string {
 	json = strings.Replace(json, " ", "", -1)
 	return json
 }
+
+// Encodes an object as gob bytes, in the same format as the files written by pokedex.WriteStructToFile
+func gobBytes(obj interface{}) []byte {
+	var b bytes.Buffer
+	gob.NewEncoder(&b).Encode(obj)
+	return b.Bytes()
+}
+
+// packFS adds an empty name index, category index and metadata dir under root to the files of a pack,
+// if the files don't have them, as a pack can't be loaded without them
+func packFS(files fstest.MapFS, root string) fstest.MapFS {
+	defaults := fstest.MapFS{
+		path.Join(root, "names.txt"):         {Data: gobBytes(map[string][]int{})},
+		path.Join(root, "category_keys.txt"): {Data: gobBytes([]string{})},
+		path.Join(root, "metadata"):          {Mode: fs.ModeDir},
+	}
+	for fpath, file := range defaults {
+		if _, err := fs.Stat(files, fpath); err != nil {
+			files[fpath] = file
+		}
+	}
+	return files
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tmck-code/pokesay/src/pokedex"
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func listChoice(id string, name string, japanese string, categories ...string) pokesay.Choice {
+	return pokesay.Choice{
+		ID:       id,
+		Metadata: pokedex.PokemonMetadata{Name: name, JapaneseName: japanese},
+		Entry:    pokedex.PokemonEntryMapping{Categories: categories, ID: id},
+	}
+}
+
+var listChoices []pokesay.Choice = []pokesay.Choice{
+	listChoice("pikachu/gen8/shiny", "Pikachu", "ピカチュウ", "small", "gen8", "shiny"),
+	listChoice("onix/gen7x/regular", "Onix", "イワーク", "big", "gen7x", "regular"),
+	listChoice("eevee/gen8/regular", "Eevee", "イーブイ", "medium", "gen8", "regular"),
+}
+
+func ids(choices []pokesay.Choice) []string {
+	result := make([]string, 0)
+	for _, choice := range choices {
+		result = append(result, choice.ID)
+	}
+	return result
+}
+
+func TestCategoryFilter(test *testing.T) {
+	for expr, expected := range map[string][]string{
+		"":                {"pikachu/gen8/shiny", "onix/gen7x/regular", "eevee/gen8/regular"},
+		"gen8":            {"pikachu/gen8/shiny", "eevee/gen8/regular"},
+		"gen8,!shiny":     {"eevee/gen8/regular"},
+		"gen7x|shiny":     {"pikachu/gen8/shiny", "onix/gen7x/regular"},
+		"big|small, gen8": {"pikachu/gen8/shiny"},
+		"gen9":            {},
+	} {
+		filter, err := pokesay.ParseCategoryFilter(expr)
+		Assert(nil, err, test)
+		Assert(expected, ids(filter.Filter(listChoices)), test)
+	}
+	for _, expr := range []string{",", "gen8,", "!", "gen8|"} {
+		_, err := pokesay.ParseCategoryFilter(expr)
+		Assert(true, err != nil, test)
+	}
+}
+
+func TestSortChoices(test *testing.T) {
+	for column, expected := range map[string][]string{
+		"id":    {"eevee/gen8/regular", "onix/gen7x/regular", "pikachu/gen8/shiny"},
+		"-name": {"pikachu/gen8/shiny", "onix/gen7x/regular", "eevee/gen8/regular"},
+		"size":  {"pikachu/gen8/shiny", "eevee/gen8/regular", "onix/gen7x/regular"},
+		"-size": {"onix/gen7x/regular", "eevee/gen8/regular", "pikachu/gen8/shiny"},
+	} {
+		choices := append([]pokesay.Choice{}, listChoices...)
+		Assert(nil, pokesay.SortChoices(choices, column), test)
+		Assert(expected, ids(choices), test)
+	}
+	Assert("invalid sort column 'weight', expected one of: id, name, japanese, categories, size", pokesay.SortChoices(listChoices, "weight").Error(), test)
+}
+
+func TestParseColumns(test *testing.T) {
+	columns, err := pokesay.ParseColumns("name, size")
+	Assert(nil, err, test)
+	Assert([]string{"name", "size"}, columns, test)
+
+	_, err = pokesay.ParseColumns("name,weight")
+	Assert("invalid column 'weight', expected one of: id, name, japanese, categories, size", err.Error(), test)
+}
+
+func TestWriteList(test *testing.T) {
+	for format, expected := range map[string]string{
+		"table": "name     japanese    size\n" +
+			"Pikachu  ピカチュウ  small\n" +
+			"Onix     イワーク    big\n",
+		"csv":   "name,japanese,size\nPikachu,ピカチュウ,small\nOnix,イワーク,big\n",
+		"plain": "Pikachu\tピカチュウ\tsmall\nOnix\tイワーク\tbig\n",
+	} {
+		var buf bytes.Buffer
+		Assert(nil, pokesay.WriteList(&buf, format, []string{"name", "japanese", "size"}, listChoices[:2]), test)
+		Assert(expected, buf.String(), test)
+	}
+
+	var buf bytes.Buffer
+	// the keys are in the order of the columns
+	Assert(nil, pokesay.WriteList(&buf, "json", []string{"id", "categories"}, listChoices[:1]), test)
+	Assert(
+		"[\n  {\n    \"id\": \"pikachu/gen8/shiny\",\n    \"categories\": [\n      \"small\",\n      \"gen8\",\n      \"shiny\"\n    ]\n  }\n]\n",
+		buf.String(),
+		test,
+	)
+
+	// a sprite without categories has an empty array
+	buf.Reset()
+	Assert(nil, pokesay.WriteList(&buf, "json", []string{"categories"}, []pokesay.Choice{listChoice("tux", "Tux", "")}), test)
+	Assert("[\n  {\n    \"categories\": []\n  }\n]\n", buf.String(), test)
+
+	Assert("invalid format 'xml', expected one of: json, table, csv, plain", pokesay.WriteList(&buf, "xml", []string{"id"}, listChoices).Error(), test)
+	Assert(true, pokesay.WriteList(&buf, "records", []string{"id"}, listChoices) != nil, test)
+}
+
+func TestWriteRows(test *testing.T) {
+	rows := [][]string{{"pikachu"}, {"onix"}}
+	for format, expected := range map[string]string{
+		"table": "name\npikachu\nonix\n",
+		"json":  "[\n  {\n    \"name\": \"pikachu\"\n  },\n  {\n    \"name\": \"onix\"\n  }\n]\n",
+		"csv":   "name\npikachu\nonix\n",
+		"plain": "pikachu\nonix\n",
+	} {
+		var buf bytes.Buffer
+		Assert(nil, pokesay.WriteRows(&buf, format, []string{"name"}, rows), test)
+		Assert(expected, buf.String(), test)
+	}
+}
+
+func TestNameMap(test *testing.T) {
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt": {Data: []byte("2")},
+		"names.txt": {Data: gobBytes(map[string][]int{"mr-mime": {0}, "tux": {1}})},
+		"metadata/0.metadata": {Data: gobBytes(pokedex.PokemonMetadata{Idx: "0000", Name: "Mr. Mime", Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 0, Categories: []string{"small", "gen8", "regular"}, ID: "mr-mime/gen8/regular"},
+			{EntryIndex: 1, Categories: []string{"small", "gen8", "shiny"}, ID: "mr-mime/gen8/shiny"},
+		}})},
+		"metadata/1.metadata": {Data: gobBytes(pokedex.PokemonMetadata{Idx: "0001", Name: "Tux", Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 2, ID: "tux"},
+		}})},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	choices, err := dex.ChoicesMatching("")
+	Assert(nil, err, test)
+	Assert(
+		map[string]map[string]string{
+			"mr-mime": {"mr-mime/gen8/regular": "small, gen8, regular", "mr-mime/gen8/shiny": "small, gen8, shiny"},
+			"tux":     {"tux": ""},
+		},
+		dex.NameMap(choices),
+		test,
+	)
+	choices, err = dex.ChoicesMatching("tu")
+	Assert(nil, err, test)
+	Assert(map[string]map[string]string{"tux": {"tux": ""}}, dex.NameMap(choices), test)
+}
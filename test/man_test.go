@@ -0,0 +1,43 @@
+package test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestWriteManPage(test *testing.T) {
+	var buf bytes.Buffer
+	pokesay.WriteManPage(&buf, pokesay.ManPage{
+		Version: "1.2.3",
+		Date:    "January 2025",
+		Flags: []pokesay.FlagInfo{
+			{Short: "h", Long: "help", Help: "display this help message"},
+			{Short: "l", Long: "list-names", Help: "list all names", Value: "value", Optional: true},
+			{Short: "w", Long: "width", Help: "the max speech bubble width", Value: "value", Default: "80"},
+			{Long: "config", Help: `read a config file, e.g. C:\pokesay.toml`, Value: "file"},
+		},
+		Commands: []pokesay.CommandInfo{
+			{Name: "random", Help: "print a random pokemon"},
+			{Name: "show", Usage: "<name|id>", Help: "print the pokemon with a name or ID"},
+		},
+		Categories: []string{"gen7x", "gen8", "shiny"},
+	})
+	page := buf.String()
+
+	for _, expected := range []string{
+		".TH POKESAY 1 \"January 2025\" \"pokesay 1.2.3\" \"User Commands\"\n",
+		".TP\n\\fB\\-h\\fR, \\fB\\-\\-help\\fR\nDisplay this help message.\n",
+		".TP\n\\fB\\-l\\fR, \\fB\\-\\-list\\-names\\fR[=\\fIVALUE\\fR]\nList all names.\n",
+		".TP\n\\fB\\-w\\fR, \\fB\\-\\-width\\fR=\\fIVALUE\\fR\nThe max speech bubble width. [default: 80]\n",
+		".TP\n\\fB\\-\\-config\\fR=\\fIFILE\\fR\nRead a config file, e.g. C:\\epokesay.toml.\n",
+		".TP\n\\fBrandom\\fR\nPrint a random pokemon.\n",
+		".TP\n\\fBshow\\fR \\fI<name|id>\\fR\nPrint the pokemon with a name or ID.\n",
+		".PP\ngen7x, gen8, shiny\n",
+		".EX\n    echo 'Hello, world!' | pokesay \\-n pikachu\n.EE\n",
+	} {
+		Assert(true, strings.Contains(page, expected), test)
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func readLines(message string, test *testing.T) []string {
+	lines := make([]string, 0)
+	err := pokesay.ReadLines(strings.NewReader(message), func(line string) {
+		lines = append(lines, line)
+	})
+	Assert(nil, err, test)
+	return lines
+}
+
+func TestReadLinesMinifiedJSON(test *testing.T) {
+	// a single line of JSON that is much longer than the 64KiB limit of a bufio.Scanner
+	items := make([]map[string]interface{}, 0)
+	for i := 0; i < 5000; i++ {
+		items = append(items, map[string]interface{}{"id": i, "name": fmt.Sprintf("pokemon-%04d", i), "shiny": i%2 == 0})
+	}
+	data, err := json.Marshal(items)
+	Assert(nil, err, test)
+	Assert(true, len(data) > 64*1024, test)
+
+	lines := readLines(string(data)+"\n"+"the end", test)
+	Assert([]string{string(data), "the end"}, lines, test)
+}
+
+func TestReadLinesLogDump(test *testing.T) {
+	dump := "2025-01-01 12:00:00 \x1b[32mINFO\x1b[0m started\r\n" +
+		"2025-01-01 12:00:01 \x1b[1;31mERROR\x1b[0m failed\x07\r\n" +
+		"\r\n" +
+		"progress 10%\rprogress 100%\x1b[K\n" +
+		"\tat main.go:12"
+
+	Assert(
+		[]string{
+			"2025-01-01 12:00:00 \x1b[32mINFO\x1b[0m started",
+			"2025-01-01 12:00:01 \x1b[1;31mERROR\x1b[0m failed",
+			"",
+			"progress 10%progress 100%",
+			"\tat main.go:12",
+		},
+		readLines(dump, test),
+		test,
+	)
+}
+
+func TestSanitiseLine(test *testing.T) {
+	for line, expected := range map[string]string{
+		"plain text":                                "plain text",
+		"ピカチュウ ♀":                                   "ピカチュウ ♀",
+		"\x1b[38;5;208morange\x1b[0m":               "\x1b[38;5;208morange\x1b[0m",
+		"\x1b[2J\x1b[Hcleared":                      "cleared",
+		"\x1b]0;title\x07text":                      "text",
+		"\x1b]8;;https://pokesay\x1b\\link":         "link",
+		"back\bspace\x00null\x7fdel":                "backspacenulldel",
+		"c1 \u0085control":                          "c1 control",
+		"invalid \xff\xfe utf8 \xc3":                "invalid � utf8 �",
+		"lone escape\x1b":                           "lone escape",
+		"tabs\tstay":                                "tabs\tstay",
+		"\x1b[1mbold\x1b[22m \x1b[3mitalic\x1b[23m": "\x1b[1mbold\x1b[22m \x1b[3mitalic\x1b[23m",
+	} {
+		Assert(expected, pokesay.SanitiseLine(line), test)
+	}
+}
@@ -3,10 +3,15 @@ package test
 import (
 	"embed"
 	"fmt"
+	"maps"
+	"math"
 	"os"
+	"path"
 	"testing"
+	"testing/fstest"
 
 	"github.com/tmck-code/pokesay/src/pokedex"
+	"github.com/tmck-code/pokesay/src/pokesay"
 )
 
 var (
@@ -20,14 +25,81 @@ func TestReadNames(test *testing.T) {
 	result := pokedex.ReadNames("./data/pokemon.json")
 
 	expected := map[string]pokedex.PokemonName{
-		"bulbasaur": {English: "Bulbasaur", Japanese: "フシギダネ", JapanesePhonetic: "fushigidane", Slug: "bulbasaur"},
-		"ivysaur":   {English: "Ivysaur", Japanese: "フシギソウ", JapanesePhonetic: "fushigisou", Slug: "ivysaur"},
-		"venusaur":  {English: "Venusaur", Japanese: "フシギバナ", JapanesePhonetic: "fushigibana", Slug: "venusaur"},
+		"bulbasaur": {
+			English: "Bulbasaur", Japanese: "フシギダネ", JapanesePhonetic: "fushigidane", Slug: "bulbasaur",
+			DexNumber: 1, Generation: 1,
+		},
+		"ivysaur": {
+			English: "Ivysaur", Japanese: "フシギソウ", JapanesePhonetic: "fushigisou", Slug: "ivysaur",
+			DexNumber: 2, Generation: 1,
+		},
+		"venusaur": {
+			English: "Venusaur", Japanese: "フシギバナ", JapanesePhonetic: "fushigibana", Slug: "venusaur",
+			DexNumber: 3, Generation: 1, Forms: []string{"gmax", "mega"},
+		},
+	}
+
+	Assert(expected, result, test)
+}
+
+func TestReadTypes(test *testing.T) {
+	result := pokedex.ReadTypes("./data/types.json")
+
+	expected := map[int][]string{
+		1: {"grass", "poison"},
+		2: {"grass", "poison"},
+		3: {"grass", "poison"},
+		6: {"fire", "flying"},
+	}
+
+	Assert(expected, result, test)
+}
+
+func TestAddTypes(test *testing.T) {
+	names := pokedex.ReadNames("./data/pokemon.json")
+	pokedex.AddTypes(names, pokedex.ReadTypes("./data/types.json"))
+
+	for _, name := range []string{"bulbasaur", "ivysaur", "venusaur"} {
+		Assert([]string{"grass", "poison"}, names[name].Types, test)
+	}
+}
+
+func TestReadEvolutions(test *testing.T) {
+	result := pokedex.ReadEvolutions("./data/evolutions.json")
+
+	expected := [][][]string{
+		{{"bulbasaur", "ivysaur", "venusaur"}},
+		{{"tauros"}},
+		{{"wurmple", "silcoon", "beautifly"}, {"wurmple", "cascoon", "dustox"}},
 	}
 
 	Assert(expected, result, test)
 }
 
+func TestAddEvolutions(test *testing.T) {
+	names := map[string]pokedex.PokemonName{
+		"ivysaur": {English: "Ivysaur", Slug: "ivysaur"},
+		"tauros":  {English: "Tauros", Slug: "tauros"},
+		"wurmple": {English: "Wurmple", Slug: "wurmple"},
+		"dustox":  {English: "Dustox", Slug: "dustox"},
+	}
+	pokedex.AddEvolutions(names, pokedex.ReadEvolutions("./data/evolutions.json"))
+
+	expected := map[string]pokedex.PokemonName{
+		"ivysaur": {English: "Ivysaur", Slug: "ivysaur", Evolutions: [][]string{{"bulbasaur", "ivysaur", "venusaur"}}},
+		// pokemon that don't evolve have no evolution lines
+		"tauros": {English: "Tauros", Slug: "tauros"},
+		// a pokemon is only given the lines that it is in
+		"wurmple": {
+			English: "Wurmple", Slug: "wurmple",
+			Evolutions: [][]string{{"wurmple", "silcoon", "beautifly"}, {"wurmple", "cascoon", "dustox"}},
+		},
+		"dustox": {English: "Dustox", Slug: "dustox", Evolutions: [][]string{{"wurmple", "cascoon", "dustox"}}},
+	}
+
+	Assert(expected, names, test)
+}
+
 func TestReadEntry(test *testing.T) {
 	result := pokedex.ReadPokemonCow(GOBCowData, "data/cows/1.cow")
 
@@ -69,7 +141,7 @@ func TestCreateNameMetadataMew(test *testing.T) {
 		JapaneseName:     "ミュウ",
 		JapanesePhonetic: "myuu",
 		Entries: []pokedex.PokemonEntryMapping{
-			{EntryIndex: 0, Categories: []string{"medium", "gen7x"}},
+			{EntryIndex: 0, Categories: []string{"medium", "gen7x"}, ID: "mew/gen7x"},
 		},
 	}
 
@@ -94,7 +166,7 @@ func TestCreateNameMetadataNatu(test *testing.T) {
 		JapaneseName:     "ネイティ",
 		JapanesePhonetic: "neiti",
 		Entries: []pokedex.PokemonEntryMapping{
-			{EntryIndex: 0, Categories: []string{"small", "gen8"}},
+			{EntryIndex: 0, Categories: []string{"small", "gen8"}, ID: "natu/gen8"},
 		},
 	}
 
@@ -119,8 +191,8 @@ func TestCreateNameMetadataEternatus(test *testing.T) {
 		JapaneseName:     "ムゲンダイナ",
 		JapanesePhonetic: "mugendaina",
 		Entries: []pokedex.PokemonEntryMapping{
-			{EntryIndex: 1, Categories: []string{"big", "gen8"}},
-			{EntryIndex: 2, Categories: []string{"big", "gen8"}},
+			{EntryIndex: 1, Categories: []string{"big", "gen8"}, ID: "eternatus/gen8"},
+			{EntryIndex: 2, Categories: []string{"big", "gen8", "form:eternamax"}, ID: "eternatus-eternamax/gen8"},
 		},
 	}
 	Assert(expected.Idx, result.Idx, test)
@@ -134,9 +206,179 @@ func TestCreateNameMetadataEternatus(test *testing.T) {
 		for _, resultEntry := range result.Entries {
 			if expectedEntry.EntryIndex == resultEntry.EntryIndex {
 				Assert(expectedEntry.Categories, resultEntry.Categories, test)
+				Assert(expectedEntry.ID, resultEntry.ID, test)
 				nMatched++
 			}
 		}
 	}
 	Assert(nEntries, nMatched, test)
 }
+
+func TestEntryID(test *testing.T) {
+	Assert("pikachu/gen8/shiny", pokedex.EntryID("gen8/shiny/pikachu.cow"), test)
+	Assert("pikachu-gmax/gen8/regular", pokedex.EntryID("/gen8/regular/pikachu-gmax.cow"), test)
+	Assert("tux", pokedex.EntryID("tux.cow"), test)
+}
+
+func TestCreateLegacyIDStruct(test *testing.T) {
+	metadata := []pokedex.PokemonMetadata{
+		{Entries: []pokedex.PokemonEntryMapping{{EntryIndex: 0, ID: "bulbasaur/gen8/regular"}}},
+		{Entries: []pokedex.PokemonEntryMapping{{EntryIndex: 1, ID: "ivysaur/gen8/regular"}, {EntryIndex: 2, ID: "ivysaur/gen8/shiny"}}},
+	}
+	// in the previous build, ivysaur/gen8/shiny was the 1st entry
+	previous := map[string]string{"0001.0001": "ivysaur/gen8/shiny"}
+
+	Assert(
+		map[string][2]int{"bulbasaur/gen8/regular": {0, 0}, "ivysaur/gen8/regular": {1, 1}, "ivysaur/gen8/shiny": {1, 2}},
+		pokedex.CreateIDStruct(metadata),
+		test,
+	)
+	Assert(
+		map[string]string{"0000.0000": "bulbasaur/gen8/regular", "0001.0001": "ivysaur/gen8/shiny", "0001.0002": "ivysaur/gen8/shiny"},
+		pokedex.CreateLegacyIDStruct(metadata, previous),
+		test,
+	)
+}
+
+func TestLegacyIDText(test *testing.T) {
+	legacy := map[string]string{"0001.0002": "ivysaur/gen8/shiny", "0000.0000": "bulbasaur/gen8/regular"}
+
+	result, err := pokedex.ParseLegacyIDText(pokedex.FormatLegacyIDText(legacy))
+	Assert(nil, err, test)
+	Assert(legacy, result, test)
+
+	// comments and blank lines are skipped
+	result, err = pokedex.ParseLegacyIDText([]byte("# a comment\n\n0000.0000 bulbasaur/gen8/regular\n"))
+	Assert(nil, err, test)
+	Assert(map[string]string{"0000.0000": "bulbasaur/gen8/regular"}, result, test)
+
+	_, err = pokedex.ParseLegacyIDText([]byte("0000.0000\n"))
+	Assert("invalid legacy ID on line 1: '0000.0000'", err.Error(), test)
+
+	// the committed file is valid, and has the IDs of the released assets
+	data, err := os.ReadFile("../build/legacy_ids.txt")
+	Assert(nil, err, test)
+	committed, err := pokedex.ParseLegacyIDText(data)
+	Assert(nil, err, test)
+	Assert(true, len(committed) > 0, test)
+}
+
+func TestPacksMergeIndexes(test *testing.T) {
+	embedded, err := pokedex.NewPack("embedded", packFS(fstest.MapFS{
+		"assets/total.txt": {Data: []byte("5")},
+		"assets/names.txt": {Data: gobBytes(map[string][]int{"hoothoot": {4}})},
+	}, "assets"), "assets")
+	Assert(nil, err, test)
+	Assert("assets/cows", embedded.CowDataRoot, test)
+
+	extra, err := pokedex.NewPack("extra", packFS(fstest.MapFS{
+		"total.txt":         {Data: []byte("2\n")},
+		"category_keys.txt": {Data: gobBytes([]string{"mascot", "small"})},
+		"names.txt":         {Data: gobBytes(map[string][]int{"hoothoot": {1}, "tux": {0}})},
+	}, "."), ".")
+	Assert(nil, err, test)
+	Assert("metadata", extra.MetadataRoot, test)
+
+	packs := pokedex.Packs{embedded, extra}
+
+	Assert(7, packs.Total(), test)
+	Assert(5, packs.Offset(extra), test)
+
+	pack, idx, err := packs.Locate(6)
+	Assert("extra", pack.Name, test)
+	Assert(1, idx, test)
+	Assert(nil, err, test)
+
+	pack, idx, err = packs.Locate(4)
+	Assert("embedded", pack.Name, test)
+	Assert(4, idx, test)
+
+	Assert(map[string][]int{"hoothoot": {4, 6}, "tux": {5}}, packs.Names(), test)
+	Assert([]string{"mascot", "small"}, extra.CategoryKeys(), test)
+
+	_, _, err = packs.Locate(7)
+	Assert(true, err != nil, test)
+
+	_, err = pokedex.NewPack("missing", fstest.MapFS{}, ".")
+	Assert(true, err != nil, test)
+}
+
+func TestNewPackRejectsBrokenPacks(test *testing.T) {
+	files := packFS(fstest.MapFS{"total.txt": {Data: []byte("1")}}, ".")
+	_, err := pokedex.NewPack("complete", files, ".")
+	Assert(nil, err, test)
+
+	for _, missing := range []string{"names.txt", "category_keys.txt", "metadata"} {
+		broken := maps.Clone(files)
+		delete(broken, missing)
+		_, err := pokedex.NewPack("broken", broken, ".")
+		Assert(fmt.Sprintf("could not read pack 'broken': open %s: file does not exist", missing), err.Error(), test)
+	}
+
+	// a pack dir with only a total is rejected when it is loaded, rather than when it is first used
+	dirpath := test.TempDir()
+	Assert(nil, os.WriteFile(path.Join(dirpath, "total.txt"), []byte("6"), 0644), test)
+	dex, err := pokesay.NewPokedex(files, ".")
+	Assert(nil, err, test)
+	err = dex.LoadPackDirs([]string{dirpath})
+	Assert(fmt.Sprintf("could not read pack '%s': open names.txt: no such file or directory", dirpath), err.Error(), test)
+	Assert(1, len(dex.Packs), test)
+}
+
+func TestPacksDexRange(test *testing.T) {
+	embedded, err := pokedex.NewPack("embedded", packFS(fstest.MapFS{
+		"total.txt": {Data: []byte("4")},
+		"dex.txt":   {Data: gobBytes(map[int][]int{1: {0}, 25: {2, 3}, 151: {1}})},
+	}, "."), ".")
+	Assert(nil, err, test)
+	extra, err := pokedex.NewPack("extra", packFS(fstest.MapFS{
+		"total.txt": {Data: []byte("2")},
+		"dex.txt":   {Data: gobBytes(map[int][]int{25: {1}, 1010: {0}})},
+	}, "."), ".")
+	Assert(nil, err, test)
+	packs := pokedex.Packs{embedded, extra}
+
+	Assert([]int{0, 2, 3, 1, 5}, packs.DexRange(1, 151), test)
+	Assert([]int{2, 3, 5}, packs.DexRange(25, 25), test)
+	Assert([]int{4}, packs.DexRange(152, 1010), test)
+	Assert([]int{}, packs.DexRange(2000, 3000), test)
+
+	// the whole range of ints finishes straight away, as only the known dex numbers are visited
+	Assert([]int{0, 2, 3, 1, 5, 4}, packs.DexRange(1, math.MaxInt), test)
+}
+
+func TestCreateNameMetadataDexInfo(test *testing.T) {
+	result := pokedex.CreateNameMetadata(
+		fmt.Sprintf("%04d", 0),
+		"eternatus",
+		pokedex.PokemonName{
+			English: "Eternatus", Japanese: "ムゲンダイナ", JapanesePhonetic: "mugendaina", Slug: "eternatus",
+			DexNumber: 890, Types: []string{"Poison", "Dragon"}, Generation: 8, Forms: []string{"eternamax"},
+		},
+		"data/cows/similar_names/",
+		[]string{
+			"data/cows/similar_names/gen8/eternatus-eternamax.cow",
+		},
+	)
+	Assert(890, result.DexNumber, test)
+	Assert([]string{"Poison", "Dragon"}, result.Types, test)
+	Assert(8, result.Generation, test)
+	Assert([]string{"eternamax"}, result.Forms, test)
+	Assert(
+		[]string{"big", "gen8", "type:poison", "type:dragon", "generation:8", "form:eternamax"},
+		result.Entries[0].Categories,
+		test,
+	)
+}
+
+func TestGenerationAndFormNames(test *testing.T) {
+	Assert(1, pokedex.Generation(151), test)
+	Assert(2, pokedex.Generation(152), test)
+	Assert(8, pokedex.Generation(890), test)
+	Assert(0, pokedex.Generation(0), test)
+
+	Assert("alolan", pokedex.FormName("alola"), test)
+	Assert("galarian", pokedex.FormName("galar"), test)
+	Assert("mega", pokedex.FormName("mega-x"), test)
+	Assert("gmax", pokedex.FormName("gmax"), test)
+}
@@ -2,7 +2,12 @@ package test
 
 import (
 	"embed"
+	"fmt"
+	"os"
+	"path"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/tmck-code/pokesay/src/pokedex"
 	"github.com/tmck-code/pokesay/src/pokesay"
@@ -15,6 +20,15 @@ var (
 	GOBCowNames embed.FS
 	//go:embed all:data/categories
 	GOBCategories embed.FS
+	//go:embed data/cows/*.metadata all:data/categories
+	GOBTestData embed.FS
+
+	TestPokedex pokedex.Pokedex = pokedex.Pokedex{
+		FS:           GOBTestData,
+		CategoryRoot: "data/categories",
+		MetadataRoot: "data/cows",
+		CowDataRoot:  "data/cows",
+	}
 )
 
 // Test pokemon selection algorithms -------------------------------------------
@@ -22,12 +36,12 @@ var (
 func TestChooseByName(test *testing.T) {
 	names := make(map[string][]int)
 	names["hoothoot"] = []int{4}
-	result, _ := pokesay.ChooseByName(
+	result, _, err := pokesay.ChooseByName(
 		names,
 		"hoothoot",
-		GOBCowNames,
-		"data/cows",
+		TestPokedex,
 	)
+	Assert(nil, err, test)
 
 	expected := pokedex.PokemonMetadata{
 		Name:             "Hoothoot",
@@ -42,19 +56,20 @@ func TestChooseByName(test *testing.T) {
 	}
 
 	Assert(expected, result, test)
+
+	_, _, err = pokesay.ChooseByName(names, "pikachu", TestPokedex)
+	Assert("cannot find pokemon by name 'pikachu'", err.Error(), test)
 }
 
 func TestChooseByCategory(test *testing.T) {
 	dir, _ := GOBCategories.ReadDir("data/categories/small")
 
-	metadata, entry := pokesay.ChooseByCategory(
+	metadata, entry, err := pokesay.ChooseByCategory(
 		"small",
 		dir,
-		GOBCategories,
-		"data/categories",
-		GOBCowNames,
-		"data/cows",
+		TestPokedex,
 	)
+	Assert(nil, err, test)
 
 	expectedMetadata := pokedex.PokemonMetadata{
 		Name:             "Hoothoot",
@@ -75,23 +90,54 @@ func TestChooseByCategory(test *testing.T) {
 
 	Assert(expectedMetadata, metadata, test)
 	Assert(expectedEntry, entry, test)
+
+	_, _, err = pokesay.ChooseByCategory("huge", nil, TestPokedex)
+	Assert("cannot find pokemon by category 'huge'", err.Error(), test)
 }
 
 func TestChooseByNameAndCategory(test *testing.T) {
 	names := make(map[string][]int)
 	names["hoothoot"] = []int{4}
-	metadata, entry := pokesay.ChooseByNameAndCategory(
+	metadata, entry, err := pokesay.ChooseByNameAndCategory(
 		names,
 		"hoothoot",
-		GOBCowNames,
-		"data/cows",
+		TestPokedex,
 		"small",
 	)
+	Assert(nil, err, test)
 
 	Assert("small", entry.Categories[0], test)
 	Assert("Hoothoot", metadata.Name, test)
 }
 
+func TestChooseByIndexInMemory(test *testing.T) {
+	metadata := pokedex.PokemonMetadata{
+		Idx:  "0000",
+		Name: "Tux",
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 7, Categories: []string{"small", "mascot"}},
+			{EntryIndex: 8, Categories: []string{"big", "mascot"}},
+		},
+	}
+	dex := pokedex.NewPokedex(fstest.MapFS{
+		"assets/metadata/0.metadata":  {Data: gobBytes(metadata)},
+		"assets/categories/big/0.cat": {Data: []byte("0/1")},
+	}, "assets")
+
+	resultMetadata, resultEntry, err := pokesay.ChooseByIndex(0, 8, dex)
+	Assert(nil, err, test)
+	Assert(metadata, resultMetadata, test)
+	Assert(metadata.Entries[1], resultEntry, test)
+
+	_, _, err = pokesay.ChooseByIndex(0, 9, dex)
+	Assert("could not find pokemon by index", err.Error(), test)
+
+	resultMetadata, resultEntry, err = pokesay.ChooseByCategory("big", dex.ReadCategoryDir("big"), dex)
+	Assert(nil, err, test)
+	Assert("Tux", resultMetadata.Name, test)
+	Assert(metadata.Entries[1], resultEntry, test)
+}
+
 func TestChooseByRandomIndex(test *testing.T) {
 	resultTotal, result := pokesay.ChooseByRandomIndex(GOBTotal)
 	Assert(9, resultTotal, test)
@@ -99,3 +145,333 @@ func TestChooseByRandomIndex(test *testing.T) {
 	Assert(0 <= result, true, test)
 	Assert(9 >= result, true, test)
 }
+
+func TestPokedexChoices(test *testing.T) {
+	metadata := pokedex.PokemonMetadata{
+		Idx:       "0000",
+		Name:      "Tux",
+		DexNumber: 42,
+		Entries:   []pokedex.PokemonEntryMapping{{EntryIndex: 0, Categories: []string{"small", "mascot"}}},
+	}
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"assets/total.txt":               {Data: []byte("1")},
+		"assets/names.txt":               {Data: gobBytes(map[string][]int{"tux": {0}})},
+		"assets/dex.txt":                 {Data: gobBytes(map[int][]int{42: {0}})},
+		"assets/category_keys.txt":       {Data: gobBytes([]string{"mascot", "small"})},
+		"assets/metadata/0.metadata":     {Data: gobBytes(metadata)},
+		"assets/cows/0.cow":              {Data: pokedex.Compress([]byte("<o)\n/\\_\n"))},
+		"assets/categories/mascot/0.cat": {Data: []byte("0/0")},
+	}, "assets"), "assets")
+	Assert(nil, err, test)
+
+	Assert([]string{"tux"}, dex.Names(), test)
+	Assert([]string{"mascot", "small"}, dex.Categories(), test)
+
+	expected := pokesay.Choice{ID: "0000.0000", Metadata: metadata, Entry: metadata.Entries[0], Pack: dex.Packs[0]}
+
+	for _, choose := range []func() (pokesay.Choice, error){
+		dex.Random,
+		func() (pokesay.Choice, error) { return dex.ByName("tux") },
+		func() (pokesay.Choice, error) { return dex.ByID("0000.0000") },
+		func() (pokesay.Choice, error) { return dex.ByCategory("mascot") },
+		func() (pokesay.Choice, error) { return dex.ByDex(42, 42) },
+		func() (pokesay.Choice, error) { return dex.ByDex(1, 151) },
+		func() (pokesay.Choice, error) { return dex.ByNameAndCategory("tux", "small") },
+	} {
+		choice, err := choose()
+		Assert(nil, err, test)
+		Assert(expected, choice, test)
+	}
+	sprite, err := dex.Sprite(expected)
+	Assert(nil, err, test)
+	Assert("<o)\n/\\_\n", string(sprite), test)
+
+	_, err = dex.ByName("pikachu")
+	Assert("cannot find pokemon by name 'pikachu'", err.Error(), test)
+	_, err = dex.ByCategory("big")
+	Assert("cannot find pokemon by category 'big'", err.Error(), test)
+	_, err = dex.ByIndex(1, 0)
+	Assert("could not find pokemon by index", err.Error(), test)
+	_, err = dex.ByID("0000.abcd")
+	Assert("invalid ID '0000.abcd', expected NNNN, NNNN.MMMM or an ID like pikachu/gen8/shiny", err.Error(), test)
+	_, err = dex.ByID("0025")
+	Assert("could not find pokemon by ID '0025', the nearest IDs are:\n  0000.0000", err.Error(), test)
+	_, err = dex.ByID("0000.0004")
+	Assert("could not find pokemon by ID '0000.0004', the nearest IDs are:\n  0000.0000", err.Error(), test)
+	_, err = dex.ByDex(1, 41)
+	Assert("cannot find pokemon by national dex numbers 1-41", err.Error(), test)
+}
+
+func TestPokedexUnreadablePack(test *testing.T) {
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt":           {Data: []byte("2")},
+		"names.txt":           {Data: gobBytes(map[string][]int{"missingno": {0}, "tux": {1}})},
+		"dex.txt":             {Data: gobBytes(map[int][]int{0: {0}, 42: {1}})},
+		"metadata/0.metadata": {Data: gobBytes(pokedex.PokemonMetadata{Idx: "0000", Name: "MissingNo"})},
+		"metadata/1.metadata": {Data: []byte("not a gob")},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	// pokemon without any sprites are reported instead of panicking
+	_, err = dex.ByDex(0, 0)
+	Assert("pokemon 'MissingNo' has no sprites", err.Error(), test)
+	_, err = dex.ByID("0000")
+	Assert("pokemon 'MissingNo' has no sprites", err.Error(), test)
+
+	// unreadable metadata is returned as an error naming the pack
+	_, err = dex.ByDex(42, 42)
+	Assert(true, strings.HasPrefix(err.Error(), "could not read pack 'embedded': invalid metadata file metadata/1.metadata: "), test)
+	_, err = dex.Choices()
+	Assert(true, strings.HasPrefix(err.Error(), "could not read pack 'embedded': invalid metadata file metadata/1.metadata: "), test)
+	_, err = dex.Sprite(pokesay.Choice{Entry: pokedex.PokemonEntryMapping{EntryIndex: 3}, Pack: dex.Packs[0]})
+	Assert("could not read pack 'embedded': open cows/3.cow: file does not exist", err.Error(), test)
+}
+
+func TestPokedexLookupAndSearch(test *testing.T) {
+	tux := pokedex.PokemonMetadata{
+		Idx:  "0000",
+		Name: "Tux",
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 0, Categories: []string{"small"}, ID: "tux/regular"},
+			{EntryIndex: 1, Categories: []string{"small"}, ID: "tux/shiny"},
+		},
+	}
+	gopher := pokedex.PokemonMetadata{
+		Idx:     "0001",
+		Name:    "Gopher",
+		Entries: []pokedex.PokemonEntryMapping{{EntryIndex: 2, Categories: []string{"big"}, ID: "gopher/regular"}},
+	}
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt":           {Data: []byte("2")},
+		"names.txt":           {Data: gobBytes(map[string][]int{"tux": {0}, "gopher": {1}})},
+		"metadata/0.metadata": {Data: gobBytes(tux)},
+		"metadata/1.metadata": {Data: gobBytes(gopher)},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	ids := func(choices []pokesay.Choice) []string {
+		result := make([]string, 0)
+		for _, choice := range choices {
+			result = append(result, choice.ID)
+		}
+		return result
+	}
+
+	choices, err := dex.Lookup("tux")
+	Assert(nil, err, test)
+	Assert([]string{"tux/regular", "tux/shiny"}, ids(choices), test)
+
+	_, err = dex.Lookup("pikachu")
+	Assert("cannot find pokemon by name 'pikachu'", err.Error(), test)
+
+	all, err := dex.Choices()
+	Assert(nil, err, test)
+	Assert([]string{"tux/regular", "tux/shiny", "gopher/regular"}, ids(all), test)
+	Assert([]string{"tux/regular", "tux/shiny"}, ids(dex.Sprites(choices[1])), test)
+
+	Assert([]string{"gopher", "tux"}, dex.Search(""), test)
+	Assert([]string{"gopher"}, dex.Search("OPH"), test)
+	Assert([]string{}, dex.Search("pikachu"), test)
+}
+
+func TestPokedexEvolutions(test *testing.T) {
+	evolutions := [][]string{{"egg", "tux", "emperor"}, {"egg", "tux", "gopher"}}
+	tux := pokedex.PokemonMetadata{
+		Idx:        "0000",
+		Name:       "Tux",
+		Evolutions: evolutions,
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 0, Categories: []string{"small", "regular"}, ID: "tux/regular"},
+			{EntryIndex: 1, Categories: []string{"small", "shiny"}, ID: "tux/shiny"},
+		},
+	}
+	egg := pokedex.PokemonMetadata{
+		Idx:        "0001",
+		Name:       "Egg",
+		Evolutions: evolutions,
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 2, Categories: []string{"small", "regular"}, ID: "egg/regular"},
+			{EntryIndex: 3, Categories: []string{"small", "shiny"}, ID: "egg/shiny"},
+		},
+	}
+	gopher := pokedex.PokemonMetadata{
+		Idx:        "0002",
+		Name:       "Gopher",
+		Evolutions: [][]string{{"egg", "tux", "gopher"}},
+		Entries:    []pokedex.PokemonEntryMapping{{EntryIndex: 4, Categories: []string{"big", "regular"}, ID: "gopher/regular"}},
+	}
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt":           {Data: []byte("3")},
+		"names.txt":           {Data: gobBytes(map[string][]int{"tux": {0}, "egg": {1}, "gopher": {2}})},
+		"metadata/0.metadata": {Data: gobBytes(tux)},
+		"metadata/1.metadata": {Data: gobBytes(egg)},
+		"metadata/2.metadata": {Data: gobBytes(gopher)},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	ids := func(lines [][]pokesay.Choice) [][]string {
+		result := make([][]string, 0)
+		for _, line := range lines {
+			lineIDs := make([]string, 0)
+			for _, choice := range line {
+				lineIDs = append(lineIDs, choice.ID)
+			}
+			result = append(result, lineIDs)
+		}
+		return result
+	}
+
+	// the sprites that share the most categories with the chosen sprite are used, and
+	// emperor isn't in the pokedex, so its line is left with only egg & tux
+	choices, err := dex.Lookup("tux")
+	Assert(nil, err, test)
+	choice := choices[1]
+	Assert("tux/shiny", choice.ID, test)
+	Assert([][]string{{"egg/shiny", "tux/shiny"}, {"egg/shiny", "tux/shiny", "gopher/regular"}}, ids(dex.Evolutions(choice)), test)
+
+	choices, err = dex.Lookup("gopher")
+	Assert(nil, err, test)
+	choice = choices[0]
+	Assert([][]string{{"egg/regular", "tux/regular", "gopher/regular"}}, ids(dex.Evolutions(choice)), test)
+
+	// a pokemon that doesn't evolve has no lines
+	choice.Metadata.Evolutions = nil
+	Assert([][]string{}, ids(dex.Evolutions(choice)), test)
+}
+
+func TestParseID(test *testing.T) {
+	for id, expected := range map[string][2]int{"0025": {25, -1}, "25": {25, -1}, "0025.0123": {25, 123}, " 1.2 ": {1, 2}} {
+		idx, entryIdx, err := pokesay.ParseID(id)
+		Assert(nil, err, test)
+		Assert(expected, [2]int{idx, entryIdx}, test)
+	}
+	for _, id := range []string{"", "abc", "abc.def", "1.", ".1", "-1", "1.-1", "1.2.3"} {
+		_, _, err := pokesay.ParseID(id)
+		Assert(true, err != nil, test)
+	}
+}
+
+func TestPokedexStableIDs(test *testing.T) {
+	metadata := pokedex.PokemonMetadata{
+		Idx:  "0000",
+		Name: "Tux",
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 0, Categories: []string{"small", "regular"}, ID: "tux/regular"},
+			{EntryIndex: 1, Categories: []string{"small", "shiny"}, ID: "tux/shiny"},
+		},
+	}
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt":           {Data: []byte("1")},
+		"ids.txt":             {Data: gobBytes(map[string][2]int{"tux/regular": {0, 0}, "tux/shiny": {0, 1}})},
+		"legacy_ids.txt":      {Data: gobBytes(map[string]string{"0000.0000": "tux/shiny", "0000.0001": "tux/gmax"})},
+		"metadata/0.metadata": {Data: gobBytes(metadata)},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	choice, err := dex.ByID("tux/regular")
+	Assert(nil, err, test)
+	Assert(metadata.Entries[0], choice.Entry, test)
+	Assert("tux/regular", choice.ID, test)
+
+	// 0000.0000 was tux/shiny in a previous build
+	choice, err = dex.ByID("0000.0000")
+	Assert(nil, err, test)
+	Assert("tux/shiny", choice.ID, test)
+
+	_, err = dex.ByID("0000.0001")
+	Assert("pokemon with ID 'tux/gmax' is no longer available", err.Error(), test)
+	_, err = dex.ByID("pikachu/gen8/shiny")
+	Assert("could not find pokemon by ID 'pikachu/gen8/shiny'", err.Error(), test)
+	_, err = dex.ByID("tux/gen8")
+	Assert("could not find pokemon by ID 'tux/gen8', the nearest IDs are:\n  tux/regular\n  tux/shiny", err.Error(), test)
+
+	// a metadata index on its own chooses a random entry
+	choice, err = dex.ByID("0000")
+	Assert(nil, err, test)
+	Assert("tux/", choice.ID[:4], test)
+}
+
+// buildLegacyIDs does what the pokedex indexer does with the committed legacy ID text file for a build, and returns
+// the pokedex of the build
+func buildLegacyIDs(metadata []pokedex.PokemonMetadata, fpath string, test *testing.T) *pokesay.Pokedex {
+	legacy := pokedex.CreateLegacyIDStruct(metadata, pokedex.ReadLegacyIDTextFile(fpath))
+	pokedex.WriteLegacyIDTextFile(legacy, fpath)
+
+	fsys := fstest.MapFS{
+		"total.txt":      {Data: []byte(fmt.Sprint(len(metadata)))},
+		"ids.txt":        {Data: gobBytes(pokedex.CreateIDStruct(metadata))},
+		"legacy_ids.txt": {Data: gobBytes(legacy)},
+	}
+	for i, m := range metadata {
+		fsys[pokedex.MetadataFpath("metadata", i)] = &fstest.MapFile{Data: gobBytes(m)}
+	}
+	dex, err := pokesay.NewPokedex(packFS(fsys, "."), ".")
+	Assert(nil, err, test)
+	return dex
+}
+
+func TestLegacyIDsAcrossBuilds(test *testing.T) {
+	entry := func(entryIdx int, id string) pokedex.PokemonEntryMapping {
+		return pokedex.PokemonEntryMapping{EntryIndex: entryIdx, ID: id}
+	}
+	// the committed file starts with the numeric IDs of a release:
+	// 0000.0000 bulbasaur/gen8/regular, 0001.0001 ivysaur/gen8/regular & 0001.0002 ivysaur/gen8/shiny
+	fpath := path.Join(test.TempDir(), "legacy_ids.txt")
+	data, err := os.ReadFile("data/legacy_ids.txt")
+	Assert(nil, err, test)
+	Assert(nil, os.WriteFile(fpath, data, 0644), test)
+
+	// 1st build: a bulbasaur sprite is added, which moves the ivysaur entries along by 1
+	buildLegacyIDs([]pokedex.PokemonMetadata{
+		{Idx: "0000", Name: "Bulbasaur", Entries: []pokedex.PokemonEntryMapping{
+			entry(0, "bulbasaur/gen8/regular"), entry(1, "bulbasaur/gen8/shiny"),
+		}},
+		{Idx: "0001", Name: "Ivysaur", Entries: []pokedex.PokemonEntryMapping{
+			entry(2, "ivysaur/gen8/regular"), entry(3, "ivysaur/gen8/shiny"),
+		}},
+	}, fpath, test)
+
+	// 2nd build: a new pokemon is added before the others, which moves all of the metadata & entry indexes along
+	dex := buildLegacyIDs([]pokedex.PokemonMetadata{
+		{Idx: "0000", Name: "Abra", Entries: []pokedex.PokemonEntryMapping{
+			entry(0, "abra/gen8/regular"),
+		}},
+		{Idx: "0001", Name: "Bulbasaur", Entries: []pokedex.PokemonEntryMapping{
+			entry(1, "bulbasaur/gen8/regular"), entry(2, "bulbasaur/gen8/shiny"),
+		}},
+		{Idx: "0002", Name: "Ivysaur", Entries: []pokedex.PokemonEntryMapping{
+			entry(3, "ivysaur/gen8/regular"), entry(4, "ivysaur/gen8/shiny"),
+		}},
+	}, fpath, test)
+
+	for id, expected := range map[string]string{
+		// the release
+		"0000.0000": "bulbasaur/gen8/regular",
+		"0001.0001": "ivysaur/gen8/regular",
+		"0001.0002": "ivysaur/gen8/shiny",
+		// the 1st build
+		"0000.0001": "bulbasaur/gen8/shiny",
+		"0001.0003": "ivysaur/gen8/shiny",
+		// the 2nd build
+		"0002.0004": "ivysaur/gen8/shiny",
+	} {
+		choice, err := dex.ByID(id)
+		Assert(nil, err, test)
+		Assert(expected, choice.ID, test)
+	}
+
+	// the committed file has the IDs of all the builds
+	Assert(7, len(pokedex.ReadLegacyIDTextFile(fpath)), test)
+}
+
+func TestParseDexRange(test *testing.T) {
+	for token, expected := range map[string][2]int{"25": {25, 25}, "1-151": {1, 151}, " 4 - 6 ": {4, 6}} {
+		lo, hi, err := pokesay.ParseDexRange(token)
+		Assert(nil, err, test)
+		Assert(expected, [2]int{lo, hi}, test)
+	}
+	for _, token := range []string{"", "0", "pikachu", "151-1", "1-", "-1"} {
+		_, _, err := pokesay.ParseDexRange(token)
+		Assert(true, err != nil, test)
+	}
+}
@@ -461,3 +461,112 @@ func TestReverseANSIString(test *testing.T) {
 		})
 	}
 }
+
+func TestFlipVerticalANSIString(test *testing.T) {
+	testCases := []struct {
+		name     string
+		input    [][]pokesay.ANSILineToken
+		expected [][]pokesay.ANSILineToken
+	}{
+		{
+			name: "Half-blocks are swapped, and keep their colours",
+			// a red top half over a transparent bottom half, then a green bottom half under a blue top half
+			input: [][]pokesay.ANSILineToken{
+				{{FG: "\x1b[38;5;160m", BG: "\x1b[49m", T: "▀▀"}},
+				{{FG: "\x1b[38;5;46m", BG: "\x1b[48;5;21m", T: "▄ "}},
+			},
+			expected: [][]pokesay.ANSILineToken{
+				{{FG: "\x1b[38;5;46m", BG: "\x1b[48;5;21m", T: "▀ "}},
+				{{FG: "\x1b[38;5;160m", BG: "\x1b[49m", T: "▄▄"}},
+			},
+		},
+		{
+			name: "Quadrant blocks are swapped top-to-bottom, and other text is unchanged",
+			input: [][]pokesay.ANSILineToken{
+				{{FG: "", BG: "", T: "▘▝▚▛▜ A█▌"}},
+			},
+			expected: [][]pokesay.ANSILineToken{
+				{{FG: "", BG: "", T: "▖▗▞▙▟ A█▌"}},
+			},
+		},
+		{
+			name: "A trailing empty line stays at the end",
+			input: [][]pokesay.ANSILineToken{
+				{{FG: "\x1b[38;5;160m", BG: "", T: "▀"}},
+				{{FG: "\x1b[38;5;46m", BG: "", T: "▄"}},
+				{},
+			},
+			expected: [][]pokesay.ANSILineToken{
+				{{FG: "\x1b[38;5;46m", BG: "", T: "▀"}},
+				{{FG: "\x1b[38;5;160m", BG: "", T: "▄"}},
+				{},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		test.Run(tc.name, func(t *testing.T) {
+			Assert(tc.expected, pokesay.FlipVerticalANSIString(tc.input), t)
+		})
+	}
+}
+
+func TestFlipANSIString(test *testing.T) {
+	input := "\x1b[38;5;160m▀▘ \x1b[38;5;46m▄\n▌\x1b[48;5;21m▄▄"
+
+	Assert(
+		pokesay.ReverseANSIString(pokesay.TokeniseANSIString(strings.ReplaceAll(input, "▘", "▝"))),
+		pokesay.FlipANSIString(pokesay.TokeniseANSIString(strings.ReplaceAll(input, "▌", "▐")), pokesay.FlipHorizontal),
+		test,
+	)
+	Assert(
+		[][]pokesay.ANSILineToken{
+			{{FG: "\x1b[38;5;46m", BG: "\x1b[49m", T: "▌"}, {FG: "\x1b[38;5;46m", BG: "\x1b[48;5;21m", T: "▀▀"}},
+			{{FG: "\x1b[38;5;160m", BG: "", T: "▄▖ "}, {FG: "\x1b[38;5;46m", BG: "", T: "▀"}},
+		},
+		pokesay.FlipANSIString(pokesay.TokeniseANSIString(input), pokesay.FlipVertical),
+		test,
+	)
+	// flipping both ways is the same as flipping one way, then the other
+	Assert(
+		pokesay.FlipANSIString(pokesay.FlipANSIString(pokesay.TokeniseANSIString(input), pokesay.FlipVertical), pokesay.FlipHorizontal),
+		pokesay.FlipANSIString(pokesay.TokeniseANSIString(input), pokesay.FlipBoth),
+		test,
+	)
+}
+
+func TestJoinANSIStrings(test *testing.T) {
+	red := pokesay.ANSILineToken{FG: "\x1b[38;5;160m", BG: "\x1b[49m", T: "▀▀"}
+	green := pokesay.ANSILineToken{FG: "\x1b[38;5;46m", BG: "\x1b[49m", T: "▄"}
+	blue := pokesay.ANSILineToken{FG: "\x1b[38;5;21m", BG: "\x1b[48;5;46m", T: "▀▀"}
+	reset := func(text string) pokesay.ANSILineToken {
+		return pokesay.ANSILineToken{FG: "\x1b[0m", BG: "", T: text}
+	}
+
+	// the shorter sprite is aligned along the bottom, and the arrow is halfway up it
+	result := pokesay.JoinANSIStrings(
+		[][][]pokesay.ANSILineToken{
+			{{red}},
+			{{green}, {blue}, {green}},
+		},
+		" > ",
+	)
+	expected := [][]pokesay.ANSILineToken{
+		{reset("  "), reset("   "), green},
+		{reset("  "), reset("   "), blue},
+		{red, reset(""), reset(" > "), green},
+	}
+	Assert(expected, result, test)
+}
+
+func TestParseFlip(test *testing.T) {
+	for value, expected := range map[string]string{
+		"": pokesay.FlipHorizontal, "h": pokesay.FlipHorizontal, "true": pokesay.FlipHorizontal,
+		"v": pokesay.FlipVertical, "hv": pokesay.FlipBoth, "VH": pokesay.FlipBoth, "false": "",
+	} {
+		result, err := pokesay.ParseFlip(value)
+		Assert(nil, err, test)
+		Assert(expected, result, test)
+	}
+	_, err := pokesay.ParseFlip("x")
+	Assert("invalid flip 'x', expected one of: h, v, hv", err.Error(), test)
+}
@@ -0,0 +1,73 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestMatchGuess(test *testing.T) {
+	names := []string{"bulbasaur", "charmander", "charmeleon", "mr. mime", "flabébé", "nidoran♀", "mew"}
+
+	for guess, expected := range map[string]string{
+		"Bulbasaur":  "bulbasaur",
+		"bulbasor\n": "bulbasaur",
+		"charmeleon": "charmeleon",
+		"charmandr":  "charmander",
+		"Mr Mime":    "mr. mime",
+		"mr-mime":    "mr. mime",
+		"flabebe":    "flabébé",
+		"nidoranf":   "nidoran♀",
+		"mew":        "mew",
+		"mewtwo":     "",
+		"pikachu":    "",
+		"  ":         "",
+		"bulba":      "",
+	} {
+		Assert(expected, pokesay.MatchGuess(guess, names), test)
+	}
+}
+
+func TestIsSameName(test *testing.T) {
+	Assert(true, pokesay.IsSameName("Mr. Mime", "mr-mime"), test)
+	Assert(true, pokesay.IsSameName("Farfetch'd", "farfetchd"), test)
+	Assert(false, pokesay.IsSameName("Mew", "Mewtwo"), test)
+}
+
+func TestQuizStateRecord(test *testing.T) {
+	state := pokesay.QuizState{}
+	for _, correct := range []bool{true, true, false, true} {
+		state.Record(correct)
+	}
+	Assert(pokesay.QuizState{Streak: 1, Best: 2, Played: 4, Correct: 3}, state, test)
+}
+
+func TestQuizStateFile(test *testing.T) {
+	fpath := filepath.Join(test.TempDir(), "pokesay", "quiz.json")
+
+	state, err := pokesay.ReadQuizState(fpath)
+	Assert(nil, err, test)
+	Assert(pokesay.QuizState{}, state, test)
+
+	state.Record(true)
+	Assert(nil, pokesay.WriteQuizState(fpath, state), test)
+
+	read, err := pokesay.ReadQuizState(fpath)
+	Assert(nil, err, test)
+	Assert(state, read, test)
+
+	os.WriteFile(fpath, []byte("{"), 0644)
+	_, err = pokesay.ReadQuizState(fpath)
+	Assert("could not read quiz state "+fpath+": unexpected end of JSON input", err.Error(), test)
+}
+
+func TestQuizStateFpath(test *testing.T) {
+	test.Setenv("XDG_STATE_HOME", "/tmp/state")
+	Assert("/tmp/state/pokesay/quiz.json", pokesay.QuizStateFpath(), test)
+
+	test.Setenv("XDG_STATE_HOME", "")
+	test.Setenv("HOME", "/home/ash")
+	Assert("/home/ash/.local/state/pokesay/quiz.json", pokesay.QuizStateFpath(), test)
+}
@@ -0,0 +1,68 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseScale(test *testing.T) {
+	for value, expected := range map[string]float64{"0.5": 0.5, "1": 1, "2": 2, " 3 ": 3, "8": 8} {
+		result, err := pokesay.ParseScale(value)
+		Assert(nil, err, test)
+		Assert(expected, result, test)
+	}
+	for _, value := range []string{"0", "-1", "9", "half", ""} {
+		_, err := pokesay.ParseScale(value)
+		Assert("invalid scale '"+value+"', expected a number greater than 0 and up to 8, e.g. 0.5 or 2", err.Error(), test)
+	}
+}
+
+func TestScaleANSIString(test *testing.T) {
+	// a red pixel in the top left, and a green pixel in the bottom right
+	sprite := pokesay.TokeniseANSIString("\x1b[38;5;160m▀\x1b[38;5;46m▄\n")
+
+	doubled := pokesay.ScaleANSIString(sprite, 2)
+	Assert(
+		[][]pokesay.ANSILineToken{
+			{{FG: "\x1b[38;5;160m", BG: "\x1b[48;5;160m", T: "▄▄"}},
+			{{FG: "\x1b[39m", BG: "\x1b[49m", T: "  "}, {FG: "\x1b[38;5;46m", BG: "\x1b[48;5;46m", T: "▄▄"}},
+		},
+		doubled,
+		test,
+	)
+	Assert(
+		[][]pokesay.ANSILineToken{
+			{{FG: "\x1b[38;5;160m", BG: "\x1b[49m", T: "▀"}, {FG: "\x1b[38;5;46m", BG: "\x1b[49m", T: "▄"}},
+		},
+		pokesay.ScaleANSIString(doubled, 0.5),
+		test,
+	)
+	Assert(sprite, pokesay.ScaleANSIString(sprite, 1), test)
+}
+
+func TestScaleANSIStringBackground(test *testing.T) {
+	// spaces are drawn with the background colour, and the trailing transparent cells are removed
+	sprite := pokesay.TokeniseANSIString("\x1b[48;5;21m \x1b[49m  \n")
+
+	Assert(
+		[][]pokesay.ANSILineToken{
+			{{FG: "\x1b[38;5;21m", BG: "\x1b[48;5;21m", T: "▄▄▄"}},
+			{{FG: "\x1b[38;5;21m", BG: "\x1b[48;5;21m", T: "▄▄▄"}},
+			{{FG: "\x1b[38;5;21m", BG: "\x1b[48;5;21m", T: "▄▄▄"}},
+		},
+		pokesay.ScaleANSIString(sprite, 3),
+		test,
+	)
+}
+
+func TestScaleForHeight(test *testing.T) {
+	// 3 lines high
+	sprite := pokesay.TokeniseANSIString("\x1b[38;5;46m▄\n█\n▀\n")
+
+	Assert(1.0, pokesay.ScaleForHeight(sprite, 1, 0), test)
+	Assert(1.0, pokesay.ScaleForHeight(sprite, 1, 3), test)
+	Assert(2.0, pokesay.ScaleForHeight(sprite, 2, 6), test)
+	Assert(1.0/3.0, pokesay.ScaleForHeight(sprite, 1, 1), test)
+	Assert(1, len(pokesay.ScaleANSIString(sprite, pokesay.ScaleForHeight(sprite, 2, 1))), test)
+}
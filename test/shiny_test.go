@@ -0,0 +1,69 @@
+package test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/tmck-code/pokesay/src/pokedex"
+	"github.com/tmck-code/pokesay/src/pokesay"
+)
+
+func TestParseShinyOdds(test *testing.T) {
+	for value, expected := range map[string]float64{"1/4096": 1.0 / 4096, " 1 / 2 ": 0.5, "0.25": 0.25, "1": 1} {
+		result, err := pokesay.ParseShinyOdds(value)
+		Assert(nil, err, test)
+		Assert(expected, result, test)
+	}
+	for _, value := range []string{"", "0", "2", "1/0", "-1/2", "3/2", "one/4096", "1/"} {
+		_, err := pokesay.ParseShinyOdds(value)
+		Assert(true, err != nil, test)
+	}
+	_, err := pokesay.ParseShinyOdds("1/0")
+	Assert("invalid shiny odds '1/0', expected a fraction or probability greater than 0 and up to 1, e.g. 1/4096 or 0.5", err.Error(), test)
+}
+
+func TestPokedexWithShiny(test *testing.T) {
+	tux := pokedex.PokemonMetadata{
+		Idx:  "0000",
+		Name: "Tux",
+		Entries: []pokedex.PokemonEntryMapping{
+			{EntryIndex: 0, Categories: []string{"small", "gen7x", "regular"}, ID: "tux/gen7x/regular"},
+			{EntryIndex: 1, Categories: []string{"small", "gen8", "regular"}, ID: "tux/gen8/regular"},
+			{EntryIndex: 2, Categories: []string{"small", "gen8", "shiny"}, ID: "tux/gen8/shiny"},
+		},
+	}
+	gopher := pokedex.PokemonMetadata{
+		Idx:     "0001",
+		Name:    "Gopher",
+		Entries: []pokedex.PokemonEntryMapping{{EntryIndex: 3, Categories: []string{"big", "regular"}, ID: "gopher/regular"}},
+	}
+	dex, err := pokesay.NewPokedex(packFS(fstest.MapFS{
+		"total.txt":           {Data: []byte("2")},
+		"names.txt":           {Data: gobBytes(map[string][]int{"tux": {0}, "gopher": {1}})},
+		"metadata/0.metadata": {Data: gobBytes(tux)},
+		"metadata/1.metadata": {Data: gobBytes(gopher)},
+	}, "."), ".")
+	Assert(nil, err, test)
+
+	choices, err := dex.Lookup("tux")
+	Assert(nil, err, test)
+	gen7x, gen8, shiny := choices[0], choices[1], choices[2]
+
+	// the sprite with the same other categories is used
+	Assert("tux/gen8/shiny", dex.WithShiny(gen8, true).ID, test)
+	Assert("tux/gen8/regular", dex.WithShiny(shiny, false).ID, test)
+	// sprites that are already shiny (or regular) are kept
+	Assert("tux/gen8/shiny", dex.WithShiny(shiny, true).ID, test)
+	Assert("tux/gen7x/regular", dex.WithShiny(gen7x, false).ID, test)
+	// if there's no sprite with the same other categories, another shiny sprite is used
+	Assert("tux/gen8/shiny", dex.WithShiny(gen7x, true).ID, test)
+	Assert(true, pokesay.IsShiny(dex.WithShiny(gen7x, true)), test)
+
+	// if the pokemon has no shiny sprites, the sprite is kept
+	choices, err = dex.Lookup("gopher")
+	Assert(nil, err, test)
+	Assert("gopher/regular", dex.WithShiny(choices[0], true).ID, test)
+
+	// odds of 1 are always shiny
+	Assert("tux/gen8/shiny", dex.RollShiny(gen8, 1).ID, test)
+}